@@ -0,0 +1,80 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CoarseNow 返回默认时间轮最近一次 tick 的时间, 见 Wheel.Now。
+// 所有 P 读同一个 wheel 的 clock: 不同 wheel 的 tick 相位不同, 在不同的 P 上读不同的 wheel 时间可能倒退
+func CoarseNow() time.Time {
+	return defaultWheelShard.Now()
+}
+
+// Since 按默认时间轮的 tick 精度返回 CoarseNow() - t
+func Since(t time.Time) time.Duration {
+	return defaultWheelShard.Since(t)
+}
+
+// Until 按默认时间轮的 tick 精度返回 t - CoarseNow()
+func Until(t time.Time) time.Duration {
+	return defaultWheelShard.Until(t)
+}
+
+// Now 返回时间轮最近一次 tick 的时间, 只是一次 atomic 读, 比 time.Now() 便宜很多,
+// 适合只需要 tick 精度的访问时间之类的场景。时间由 jiffies 推算, 精度为毫秒, 不会倒退;
+// 返回值最多落后一个 tick(tick goroutine 被延迟时会更多, 追上之后向前跳),
+// 不带 monotonic clock, 和 time.Unix 返回的时间一样。
+// ManualWheel/SimWheel 中返回当前的虚拟时间
+func (w *Wheel) Now() time.Time {
+	if w.single {
+		return w.now()
+	}
+	return time.Unix(0, atomic.LoadInt64(&w.clock)*int64(time.Millisecond))
+}
+
+// clockAt 返回 jiffies 为 j 时的时间(UnixNano): 处理完第 j-1 个槽, 也就是 clockBase 之后经过了 j-clockJiffies 个 tick。
+// 调用者持有 w.Lock
+func (w *Wheel) clockAt(j uint64) int64 {
+	return w.clockBase + int64(j-w.clockJiffies)*int64(w.tick)
+}
+
+// rebaseClock 把当前 jiffies 的时间设为 base, 之后按当前的 tick 推算, 调用者持有 w.Lock
+func (w *Wheel) rebaseClock(base int64) {
+	w.clockBase, w.clockJiffies = base, w.jiffies
+}
+
+// tickClock 在 jiffies 增加之后更新 clock, 调用者持有 w.Lock
+func (w *Wheel) tickClock() {
+	atomic.StoreInt64(&w.clock, w.clockAt(w.jiffies)/int64(time.Millisecond))
+}
+
+// syncClock 在 run goroutine 中每个 tick 之后调用: tick goroutine 被延迟时 time.Ticker 会丢掉 tick,
+// 由 jiffies 推算的时间落后于 now 超过一个 tick 就把 clock 向前对齐到 now, clock 只会向前跳, 不会倒退
+func (w *Wheel) syncClock(now time.Time) {
+	tick, _ := w.curTick()
+	if now.UnixNano()-atomic.LoadInt64(&w.clock)*int64(time.Millisecond) < 2*int64(tick) {
+		return
+	}
+	w.Lock()
+	if base := now.UnixNano(); base-w.clockAt(w.jiffies) >= int64(w.tick) {
+		w.rebaseClock(base)
+		w.tickClock()
+	}
+	w.Unlock()
+}
+
+// Since 返回 w.Now() - t, 按 tick 向零截断
+func (w *Wheel) Since(t time.Time) time.Duration {
+	return w.truncate(w.Now().Sub(t))
+}
+
+// Until 返回 t - w.Now(), 按 tick 向零截断
+func (w *Wheel) Until(t time.Time) time.Duration {
+	return w.truncate(t.Sub(w.Now()))
+}
+
+func (w *Wheel) truncate(d time.Duration) time.Duration {
+	tick, _ := w.curTick()
+	return d - d%tick
+}
@@ -0,0 +1,112 @@
+package timer
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWheelCoarseNow 测试时间轮的 coarse clock。
+// 功能点：Now() 每个 tick 更新，和 time.Now() 的差不超过几个 tick；Since/Until 的结果是 tick 的整数倍。
+// 方法：10ms tick 的时间轮，等待几个 tick 后比较 Now() 和 time.Now()，检查 Since/Until 的精度。
+func TestWheelCoarseNow(t *testing.T) {
+	tick := 10 * time.Millisecond
+	w := newTestWheel(t, tick)
+
+	first := w.Now()
+	requireEventually(t, time.Second, func() bool { return w.Now().After(first) }, "Now() not updated by tick")
+
+	time.Sleep(5 * tick)
+	now, wall := w.Now(), time.Now()
+	if d := wall.Sub(now); d < 0 || d > 5*tick {
+		t.Fatalf("Now() is %v behind time.Now(), expected within [0, %v]", d, 5*tick)
+	}
+
+	past := wall.Add(-time.Second - 3*time.Millisecond)
+	if d := w.Since(past); d%tick != 0 || d < time.Second-5*tick || d > time.Second {
+		t.Fatalf("Since() = %v, expected a multiple of %v about 1s", d, tick)
+	}
+	future := wall.Add(time.Second + 3*time.Millisecond)
+	if d := w.Until(future); d%tick != 0 || d < time.Second || d > time.Second+5*tick {
+		t.Fatalf("Until() = %v, expected a multiple of %v about 1s", d, tick)
+	}
+}
+
+// TestCoarseNowDefault 测试包级别的 CoarseNow。
+// 功能点：CoarseNow() 和 time.Now() 的差不超过默认时间轮的几个 tick。
+// 方法：比较 CoarseNow() 和 time.Now()。
+func TestCoarseNowDefault(t *testing.T) {
+	if d := time.Since(CoarseNow()); d < 0 || d > time.Second {
+		t.Fatalf("CoarseNow() is %v behind time.Now(), expected within [0, 1s]", d)
+	}
+	if d := Since(time.Now().Add(-time.Hour)); d < time.Hour-time.Second || d > time.Hour {
+		t.Fatalf("Since(1h ago) = %v, expected about 1h", d)
+	}
+}
+
+// TestSimWheelCoarseNow 测试模拟时间轮的 Now。
+// 功能点：SimWheel 中 Wheel.Now() 返回虚拟时间，基于 *Wheel 的代码在模拟中拿到的也是虚拟时间。
+// 方法：RunUntil 之后和 callback 中检查 sim.Wheel.Now() 和 Since。
+func TestSimWheelCoarseNow(t *testing.T) {
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(time.Millisecond, start)
+	defer sim.Stop()
+
+	w := sim.Wheel
+	var at time.Duration
+	w.AfterFunc(time.Hour, func() { at = w.Since(start) })
+	sim.RunUntil(start.Add(2 * time.Hour))
+	if at < time.Hour || at > time.Hour+2*time.Millisecond {
+		t.Fatalf("Since(start) in callback = %v, expected about 1h", at)
+	}
+	if now := w.Now(); !now.Equal(start.Add(2 * time.Hour)) {
+		t.Fatalf("Now() = %v, expected 2h", now.Sub(start))
+	}
+}
+
+// TestCoarseNowMonotonic 测试 coarse clock 不会倒退。
+// 功能点：Wheel.Now 由 jiffies 推算，精度为毫秒，SetTick 改变 tick 时不倒退；CoarseNow 所有 P 读同一个时钟源，
+// 在不同的 P 上连续读也不倒退。
+// 方法：多个 goroutine 不断读 CoarseNow 和 w.Now 并检查单调和毫秒精度，同时反复 SetTick。
+func TestCoarseNowMonotonic(t *testing.T) {
+	w := newTestWheel(t, time.Millisecond)
+	stop := time.Now().Add(300 * time.Millisecond)
+	errs := make(chan string, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var lastW, lastC time.Time
+			for time.Now().Before(stop) {
+				now, coarse := w.Now(), CoarseNow()
+				if now.Before(lastW) || coarse.Before(lastC) {
+					errs <- fmt.Sprintf("clock went backwards: w.Now %v -> %v, CoarseNow %v -> %v", lastW, now, lastC, coarse)
+					return
+				}
+				if now.Nanosecond()%int(time.Millisecond) != 0 {
+					errs <- fmt.Sprintf("w.Now() = %v, expected millisecond resolution", now)
+					return
+				}
+				lastW, lastC = now, coarse
+				runtime.Gosched()
+			}
+		}()
+	}
+	for _, d := range []time.Duration{3, 1, 7, 2, 1} {
+		time.Sleep(30 * time.Millisecond)
+		if err := w.SetTick(d * time.Millisecond); err != nil {
+			t.Fatalf("SetTick() err = %v", err)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if d := time.Since(w.Now()); d < 0 || d > time.Second {
+		t.Fatalf("Now() is %v behind time.Now(), expected within [0, 1s]", d)
+	}
+}
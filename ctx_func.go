@@ -0,0 +1,61 @@
+package timer
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// runCtx 为本次执行创建 ctx, 执行中 Stop/Reset/StopWait、所属的 group Stop 或者时间轮 Stop 都会 cancel 它
+func (w *Wheel) runCtx(t *timer, now time.Time) {
+	parent := w.ctx
+	if t.group != nil {
+		parent = t.group.ctx
+	}
+	ctx, cancel := context.WithCancel(parent)
+	w.Lock()
+	if t.canceled {
+		//取出之后, 开始执行之前就被 Stop 了
+		t.canceled = false
+		cancel()
+	}
+	t.cancel = cancel
+	w.Unlock()
+
+	t.ctxF(ctx, now, t.arg...)
+
+	w.Lock()
+	t.cancel = nil
+	w.Unlock()
+	cancel()
+}
+
+// cancelRunning 调用者持有 w.Lock
+func (w *Wheel) cancelRunning(t *timer) {
+	if t.ctxF == nil {
+		return
+	}
+	if t.cancel != nil {
+		t.cancel()
+	} else if atomic.LoadInt32(&t.busy) == 1 {
+		t.canceled = true
+	}
+}
+
+func NewWheelTimerCtxFunc(d time.Duration, f func(context.Context, time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	return defaultWheelShard.NewWheelTimerCtxFunc(d, f, arg...)
+}
+
+// NewWheelTimerCtxFunc 和 NewWheelTimerFunc 一样, 但 callback 多一个 ctx:
+// 执行过程中 timer 被 Stop/Reset/StopWait 或者时间轮 Stop 时 ctx 被 cancel, 做 I/O 的 callback 可以及时退出;
+// 用 Group.TryNewWheelTimerCtxFunc 创建的 timer 在 group Stop 时也会被 cancel。
+// 周期 timer 执行中被 Stop 只会 cancel 本次执行, 不再重新加入时间轮需要用 StopWait
+func (w *Wheel) NewWheelTimerCtxFunc(d time.Duration, f func(context.Context, time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	t := w.newTimer(d, 0, nil, arg...)
+	t.ctxF = f
+	if w.addTimer(t) {
+		return t
+	}
+
+	return nil
+}
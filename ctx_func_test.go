@@ -0,0 +1,142 @@
+package timer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestCtxFuncCancelledByStop 测试执行中的 ctx callback 被 Stop/Reset 取消。
+// 功能点：callback 执行中调用 Stop 或 ResetTimer，callback 收到的 ctx 被 cancel；正常执行完的 ctx 不受影响。
+// 方法：callback 阻塞在 ctx.Done() 上，分别调用 Stop 和 ResetTimer，检查 callback 能及时返回。
+func TestCtxFuncCancelledByStop(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	for _, tc := range []struct {
+		name   string
+		cancel func(*WheelTimer)
+	}{
+		{"Stop", func(tm *WheelTimer) { tm.Stop() }},
+		{"ResetTimer", func(tm *WheelTimer) { tm.ResetTimer(time.Second, 0) }},
+	} {
+		entered := make(chan struct{}, 1)
+		done := make(chan error, 1)
+		tm := w.NewWheelTimerCtxFunc(testTick, func(ctx context.Context, _ time.Time, _ ...interface{}) {
+			entered <- struct{}{}
+			select {
+			case <-ctx.Done():
+				done <- ctx.Err()
+			case <-time.After(time.Second):
+				done <- nil
+			}
+		})
+		waitStruct(t, entered, 200*time.Millisecond, tc.name+" ctx callback")
+		tc.cancel(tm)
+		select {
+		case err := <-done:
+			if err != context.Canceled {
+				t.Fatalf("%s: ctx.Err() = %v, expected context.Canceled", tc.name, err)
+			}
+		case <-time.After(200 * time.Millisecond):
+			t.Fatalf("%s: ctx callback was not cancelled", tc.name)
+		}
+	}
+
+	errc := make(chan error, 1)
+	w.NewWheelTimerCtxFunc(testTick, func(ctx context.Context, _ time.Time, _ ...interface{}) {
+		errc <- ctx.Err()
+	})
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("ctx.Err() of normal execution = %v, expected nil", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("ctx callback did not fire")
+	}
+}
+
+// TestCtxFuncCancelledByWheelStop 测试时间轮 Stop 时取消执行中的 ctx callback。
+// 功能点：wheel.Stop() 会 cancel 所有正在执行的 ctx callback。
+// 方法：callback 阻塞在 ctx.Done() 上，Stop 时间轮后检查 callback 返回。
+func TestCtxFuncCancelledByWheelStop(t *testing.T) {
+	w := NewWheel(testTick)
+	entered := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	w.NewWheelTimerCtxFunc(testTick, func(ctx context.Context, _ time.Time, _ ...interface{}) {
+		entered <- struct{}{}
+		select {
+		case <-ctx.Done():
+			done <- ctx.Err()
+		case <-time.After(time.Second):
+			done <- nil
+		}
+	})
+	waitStruct(t, entered, 200*time.Millisecond, "ctx callback")
+	w.Stop()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("ctx.Err() = %v, expected context.Canceled", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("ctx callback was not cancelled by wheel Stop")
+	}
+}
+
+// TestGroupStopCancelsCtxFunc 测试 Group.Stop。
+// 功能点：Group.Stop 停止 group 中还在时间轮里的 timer，cancel group 中正在执行的 ctx callback，
+// 不影响 group 之外的 timer；之后 group 的 Try* 接口返回 ErrGroupStopped，重复 Stop 返回 0。
+// 方法：手动推进时间轮让 group 中的 ctx callback 开始执行并阻塞在 ctx.Done() 上，再加入 group 内外的 timer，
+// Stop group 后检查 callback 返回 context.Canceled 以及 timer 数量。
+func TestGroupStopCancelsCtxFunc(t *testing.T) {
+	w := newIdleTestWheel(t)
+	g := w.NewGroup(0, RejectNew)
+	entered := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	_, err := g.TryNewWheelTimerCtxFunc(0, func(ctx context.Context, _ time.Time, _ ...interface{}) {
+		entered <- struct{}{}
+		select {
+		case <-ctx.Done():
+			done <- ctx.Err()
+		case <-time.After(time.Second):
+			done <- nil
+		}
+	})
+	if err != nil {
+		t.Fatalf("TryNewWheelTimerCtxFunc() err = %v, expected nil", err)
+	}
+	w.onTick()
+	waitStruct(t, entered, time.Second, "ctx callback")
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.TryNewTimer(time.Hour); err != nil {
+			t.Fatalf("TryNewTimer() err = %v, expected nil", err)
+		}
+	}
+	other := w.NewTimer(time.Hour)
+
+	if n := g.Stop(); n != 2 {
+		t.Fatalf("Stop() = %d, expected 2 pending timers stopped", n)
+	}
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("ctx.Err() = %v, expected context.Canceled", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("ctx callback was not cancelled by group Stop")
+	}
+	if n := g.Timers(); n != 0 {
+		t.Fatalf("g.Timers() = %d, expected 0", n)
+	}
+	if n := w.Timers(); n != 1 {
+		t.Fatalf("w.Timers() = %d, expected only the timer outside of the group", n)
+	}
+	if _, err := g.TryAfterFunc(time.Hour, func() {}); err != ErrGroupStopped {
+		t.Fatalf("TryAfterFunc() after Stop err = %v, expected ErrGroupStopped", err)
+	}
+	if n := g.Stop(); n != 0 {
+		t.Fatalf("second Stop() = %d, expected 0", n)
+	}
+	other.Stop()
+}
@@ -0,0 +1,9 @@
+//go:build !timerdebug
+// +build !timerdebug
+
+package timer
+
+// timerDebug 用 -tags timerdebug 开启: Release 后的 timer 不再放回 pool, 并被 poison, 之后的任何使用都会 panic
+const timerDebug = false
+
+func poisonTimer(t *timer) {}
@@ -0,0 +1,20 @@
+//go:build timerdebug
+// +build timerdebug
+
+package timer
+
+import "time"
+
+const timerDebug = true
+
+func poisonedFunc(time.Time, ...interface{}) {
+	panic("timer: released timer executed")
+}
+
+// poisonTimer 让 Release 之后的 timer 无法再被正常使用: 状态保持 InPool, 任何 Stop/ResetTimer/Release 都会 panic,
+// 如果被错误地加入时间轮, 执行时也会 panic
+func poisonTimer(t *timer) {
+	t.f = poisonedFunc
+	t.expires = ^uint64(0)
+	t.period = 0
+}
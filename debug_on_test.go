@@ -0,0 +1,28 @@
+//go:build timerdebug
+// +build timerdebug
+
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDebugPoisonsReleasedTimer 测试 timerdebug 模式下对已 Release 的 timer 的使用会 panic。
+// 功能点：Release 后的 timer 不再放回 pool，通过 *WheelTimer 直接 Stop 会立即 panic 暴露 use-after-release。
+// 方法：go test -tags timerdebug，Release 后调用 Stop，用 recover 检查 panic。
+func TestDebugPoisonsReleasedTimer(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	tm := w.NewWheelTimerFunc(time.Second, func(time.Time, ...interface{}) {})
+	if !tm.Stop() {
+		t.Fatalf("Stop returned false, expected true")
+	}
+	tm.Release()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Stop of released timer did not panic, expected panic in timerdebug mode")
+		}
+	}()
+	tm.Stop()
+}
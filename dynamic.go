@@ -0,0 +1,40 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// rearmDynamic 按 callback 返回的 next 设置下次到期时间, 至少一个 tick
+func (w *Wheel) rearmDynamic(t *timer, next time.Duration, now time.Time) {
+	tick, seq := w.curTick()
+	ticks := durationToTicks(next, tick)
+	if ticks == 0 {
+		ticks = 1
+	}
+	t.expires = atomic.LoadUint64(&w.jiffies) + ticks
+	t.tickSeq = seq
+	t.deadline = now.UnixNano() + int64(next)
+	t.interval = next
+	if t.precise {
+		w.setPreciseExpires(t, now.UnixNano())
+	}
+}
+
+func NewDynamicTimer(first time.Duration, f func(FireInfo) (next time.Duration, stop bool)) *WheelTimer {
+	return defaultWheelShard.NewDynamicTimer(first, f)
+}
+
+// NewDynamicTimer 创建间隔由 callback 决定的周期 timer: first 之后第一次执行,
+// 之后每次执行完由 wheel 按 f 返回的 next 重新加入时间轮, f 返回 stop 为 true 时不再加入。
+// 重新加入和 f 在同一个执行流程里完成, 不需要在 callback 里 ResetTimer, 也不会和外部的 Stop 竞争;
+// 执行中需要从外部停止用 StopWait。
+func (w *Wheel) NewDynamicTimer(first time.Duration, f func(FireInfo) (next time.Duration, stop bool)) *WheelTimer {
+	t := w.newTimer(first, 0, nil)
+	t.dynF = f
+	if w.addTimer(t) {
+		return t
+	}
+
+	return nil
+}
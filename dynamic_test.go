@@ -0,0 +1,77 @@
+package timer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDynamicTimerNextAndStop 测试 callback 决定下次间隔的 timer。
+// 功能点：每次执行按 callback 返回的 next 重新调度，返回 stop 后不再执行且不在时间轮中。
+// 方法：callback 返回逐渐变大的间隔，第 3 次返回 stop，检查执行次数、执行间隔和 Timers()。
+func TestDynamicTimerNextAndStop(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	fired := make(chan FireInfo, 10)
+	w.NewDynamicTimer(testTick, func(fi FireInfo) (time.Duration, bool) {
+		fired <- fi
+		return time.Duration(fi.Count) * 5 * testTick, fi.Count == 3
+	})
+
+	var last time.Time
+	for i := uint64(1); i <= 3; i++ {
+		var fi FireInfo
+		select {
+		case fi = <-fired:
+		case <-time.After(time.Second):
+			t.Fatalf("dynamic timer fire %d timeout", i)
+		}
+		if fi.Count != i {
+			t.Fatalf("fire %d Count = %d", i, fi.Count)
+		}
+		if i > 1 {
+			want := time.Duration(i-1) * 5 * testTick
+			if gap := fi.Fired.Sub(last); gap < want-testTick {
+				t.Fatalf("fire %d gap = %v, expected at least %v", i, gap, want)
+			}
+		}
+		last = fi.Fired
+	}
+	select {
+	case fi := <-fired:
+		t.Fatalf("dynamic timer fired again after stop: %+v", fi)
+	case <-time.After(20 * testTick):
+	}
+	if n := w.Timers(); n != 0 {
+		t.Fatalf("Timers() = %d, expected 0", n)
+	}
+}
+
+// TestDynamicTimerStopWait 测试外部停止 dynamic timer。
+// 功能点：执行中 StopWait 之后 callback 返回的 next 不再生效，timer 不会被重新加入。
+// 方法：callback 阻塞时调用 StopWait，放行后检查执行次数不再增加。
+func TestDynamicTimerStopWait(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	var fires int32
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	tm := w.NewDynamicTimer(testTick, func(FireInfo) (time.Duration, bool) {
+		if atomic.AddInt32(&fires, 1) == 1 {
+			entered <- struct{}{}
+			<-release
+		}
+		return testTick, false
+	})
+	waitStruct(t, entered, 200*time.Millisecond, "dynamic callback")
+	go func() {
+		time.Sleep(2 * testTick)
+		close(release)
+	}()
+	if err := tm.StopWait(context.Background()); err != nil {
+		t.Fatalf("StopWait() err = %v, expected nil", err)
+	}
+	time.Sleep(5 * testTick)
+	if n := atomic.LoadInt32(&fires); n != 1 {
+		t.Fatalf("fires = %d, expected 1", n)
+	}
+}
@@ -0,0 +1,57 @@
+package timer
+
+import (
+	"errors"
+
+	"github.com/jursonmo/timer/log"
+)
+
+var (
+	// ErrReleased 表示 handle 指向的 timer 已经 Release, 可能已经被 sync.Pool 分配给了别的调用者
+	ErrReleased = errors.New("timer: use of released timer")
+	// ErrTimerReleased 和 ErrReleased 是同一个错误
+	ErrTimerReleased = ErrReleased
+	// ErrWheelStopped 表示时间轮已经 Stop, 新加入的 timer 永远不会触发
+	ErrWheelStopped = errors.New("timer: wheel stopped")
+	// ErrTimerActive 表示 timer 还在时间轮中, 不能重复加入或 Release
+	ErrTimerActive = errors.New("timer: timer still active in wheel")
+	// ErrTimerFired 表示 timer 已经到期(准备执行、正在执行或已经执行), 无法 Stop 或 Reset
+	ErrTimerFired = errors.New("timer: timer already fired")
+	// ErrTooManyTimers 表示时间轮中的 timer 数量达到上限
+	ErrTooManyTimers = errors.New("timer: too many timers")
+	// ErrUnknownHandler 表示 SlabWheel.AddTimer 传入的 SlabHandler 没有通过 RegisterHandler 注册
+	ErrUnknownHandler = errors.New("timer: unknown slab handler")
+	// ErrWouldBlock 表示 BlockUntilFree 需要等待, 但调用者在 ManualWheel/SimWheel 中或者在派发 callback 的 goroutine 中,
+	// 等待会让时间轮无法推进, 永远等不到空位, 直接返回
+	ErrWouldBlock = errors.New("timer: BlockUntilFree would block the wheel")
+	// ErrGroupStopped 表示 Group 已经 Stop, 不能再加入新的 timer
+	ErrGroupStopped = errors.New("timer: group stopped")
+	// ErrTickFixed 表示 ManualWheel/SimWheel/MultiWheel 的 tick 不能修改
+	ErrTickFixed = errors.New("timer: tick of this wheel can not be changed")
+	// ErrInvariant 表示库内部状态不一致, 通过 WithViolationHandler 设置的 handler 报告
+	ErrInvariant = errors.New("timer: invariant violation")
+)
+
+// WithViolationHandler 设置内部状态不一致(比如重复 addTimer、Release 还在时间轮中的 timer)时的处理方式,
+// 默认是 PanicOnViolation。handler 返回后, 出错的操作会被放弃, 不会破坏时间轮的状态, 库本身不会调用 os.Exit。
+func WithViolationHandler(h func(error)) Option {
+	return func(w *Wheel) {
+		w.onViolation = h
+	}
+}
+
+// PanicOnViolation 直接 panic, 是默认的 violation handler
+func PanicOnViolation(err error) {
+	panic(err)
+}
+
+// LogViolation 返回只用 l.Errorf 记录错误的 violation handler
+func LogViolation(l log.Logger) func(error) {
+	return func(err error) {
+		l.Errorf("%v", err)
+	}
+}
+
+func (w *Wheel) violation(err error) {
+	w.onViolation(err)
+}
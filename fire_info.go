@@ -0,0 +1,146 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// FireInfo 描述一次 timer 执行的调度信息, 用于测量和应对调度延迟
+type FireInfo struct {
+	Scheduled time.Time     //请求的到期时间, 周期 timer 是本次执行对应的调度时间
+	Fired     time.Time     //实际执行时间
+	Lateness  time.Duration //Fired - Scheduled
+	Tick      uint64        //执行时 wheel 的 jiffies
+	Count     uint64        //第几次执行, 从 1 开始, 周期 timer 每次执行加 1
+	Missed    uint64        //错过的周期数, 见 WheelTimer.Missed
+}
+
+// TickEvent 是 EventTicker.C 上收到的事件
+type TickEvent struct {
+	FireInfo
+}
+
+func (w *Wheel) fireInfo(t *timer, now time.Time) FireInfo {
+	scheduled := time.Unix(0, t.deadline)
+	return FireInfo{
+		Scheduled: scheduled,
+		Fired:     now,
+		Lateness:  now.Sub(scheduled),
+		Tick:      atomic.LoadUint64(&w.jiffies),
+		Count:     t.fires,
+		Missed:    atomic.LoadUint64(&t.missed),
+	}
+}
+
+func goInfoFunc(fi FireInfo, arg ...interface{}) {
+	go arg[0].(func(FireInfo))(fi)
+}
+
+func callInfoFunc(fi FireInfo, arg ...interface{}) {
+	arg[0].(func(FireInfo))(fi)
+}
+
+// EventTicker 和 Ticker 一样周期触发, 但 C 上收到的是带调度信息的 TickEvent
+type EventTicker struct {
+	C   <-chan TickEvent
+	r   *timer
+	s   *tickSender
+	gen uint32
+}
+
+func NewEventTicker(d time.Duration, opts ...TickerOption) *EventTicker {
+	return defaultWheelShard.NewEventTicker(d, opts...)
+}
+
+func NewWheelTimerInfoFunc(d time.Duration, f func(FireInfo, ...interface{}), arg ...interface{}) *WheelTimer {
+	return defaultWheelShard.NewWheelTimerInfoFunc(d, f, arg...)
+}
+
+func (t *EventTicker) Stop() bool {
+	return t.r.stop(t.gen) == nil
+}
+
+func (t *EventTicker) Release() {
+	t.r.release(t.gen)
+}
+
+func (t *EventTicker) Reset(d time.Duration) {
+	t.r.w.resetTimer(t.r, t.gen, d, d)
+}
+
+// SetPeriodMode 见 Ticker.SetPeriodMode
+func (t *EventTicker) SetPeriodMode(mode PeriodMode) {
+	t.r.w.setPeriodMode(t.r, t.gen, mode)
+}
+
+// Dropped 返回因为 channel 满而丢弃的事件数量
+func (t *EventTicker) Dropped() uint64 {
+	return t.s.Dropped()
+}
+
+func (w *Wheel) newInfoTimer(when time.Duration, period time.Duration,
+	f func(FireInfo, ...interface{}), arg ...interface{}) *timer {
+	t := w.newTimer(when, period, nil, arg...)
+	t.infoF = f
+	return t
+}
+
+// newInfoTimerArg 和 newTimerArg 一样把唯一的参数放在 timer 自己的 argBuf 中, 不分配 []interface{}
+func (w *Wheel) newInfoTimerArg(when time.Duration, period time.Duration,
+	f func(FireInfo, ...interface{}), a interface{}) *timer {
+	t := w.newTimerArg(when, period, nil, a)
+	t.infoF = f
+	return t
+}
+
+// NewWheelTimerInfoFunc 和 NewWheelTimerFunc 一样, 但 callback 收到的是 FireInfo 而不只是执行时间
+func (w *Wheel) NewWheelTimerInfoFunc(d time.Duration, f func(FireInfo, ...interface{}), arg ...interface{}) *WheelTimer {
+	t := w.newInfoTimer(d, 0, f, arg...)
+	if w.addTimer(t) {
+		return t
+	}
+
+	return nil
+}
+
+// TickInfoFunc 和 TickFunc 一样在新的 goroutine 中执行 f, 但 f 收到本次执行的 FireInfo
+func (w *Wheel) TickInfoFunc(d time.Duration, f func(FireInfo), opts ...TickerOption) *Ticker {
+	run := goInfoFunc
+	if w.single {
+		run = callInfoFunc
+	}
+	t := w.getTicker()
+	t.r = w.newInfoTimerArg(d, d, run, f)
+	t.r.async = !w.single
+	t.gen = t.r.gen
+	newTickerConfig(opts).apply(t.r)
+
+	if w.addTimer(t.r) {
+		return t
+	}
+
+	return nil
+}
+
+// NewEventTicker 和 NewTicker 一样, 但 C 上收到的是携带 FireInfo 的 TickEvent。
+// EventTicker 的外壳和 channel 不经过 WithWrapperPool 复用, 每次创建都会分配
+func (w *Wheel) NewEventTicker(d time.Duration, opts ...TickerOption) *EventTicker {
+	cfg := newTickerConfig(opts)
+	c := make(chan TickEvent, cfg.buffer)
+	ts := cfg.newSender(w)
+	ts.ec = c
+	r := w.newInfoTimer(d, d, sendEvent, ts)
+	t := &EventTicker{
+		C:   c,
+		r:   r,
+		s:   ts,
+		gen: r.gen,
+	}
+	cfg.apply(t.r)
+
+	if w.addTimer(t.r) {
+		return t
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWheelTimerInfoFuncReportsSchedule 测试 FireInfo callback 收到的调度信息。
+// 功能点：Scheduled 应为创建时间加 d，Fired 不早于 Scheduled，Lateness 等于两者之差，一次性 timer 的 Count 为 1。
+// 方法：创建 NewWheelTimerInfoFunc，在 callback 中把 FireInfo 发出来逐项检查。
+func TestWheelTimerInfoFuncReportsSchedule(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	infos := make(chan FireInfo, 1)
+
+	const d = 5 * testTick
+	start := time.Now()
+	w.NewWheelTimerInfoFunc(d, func(fi FireInfo, args ...interface{}) {
+		if len(args) != 1 || args[0] != "key" {
+			t.Errorf("callback args = %#v, expected []interface{}{\"key\"}", args)
+		}
+		infos <- fi
+	}, "key")
+
+	var fi FireInfo
+	select {
+	case fi = <-infos:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatalf("timed out waiting for info callback")
+	}
+	if diff := fi.Scheduled.Sub(start.Add(d)); diff < 0 || diff > testTick {
+		t.Fatalf("Scheduled = %v, expected about %v", fi.Scheduled, start.Add(d))
+	}
+	if fi.Fired.Before(fi.Scheduled) {
+		t.Fatalf("Fired %v before Scheduled %v, expected not before", fi.Fired, fi.Scheduled)
+	}
+	if fi.Lateness != fi.Fired.Sub(fi.Scheduled) {
+		t.Fatalf("Lateness = %s, expected %s", fi.Lateness, fi.Fired.Sub(fi.Scheduled))
+	}
+	if fi.Count != 1 || fi.Tick == 0 {
+		t.Fatalf("Count = %d, Tick = %d, expected Count 1 and non-zero Tick", fi.Count, fi.Tick)
+	}
+	assertWheelEmpty(t, w)
+}
+
+// TestEventTickerCountsFires 测试 EventTicker 的事件内容。
+// 功能点：每个 TickEvent 的 Count 应递增，Scheduled 应按周期递增且不晚于 Fired。
+// 方法：FixedRate 模式下连续接收三个事件，检查 Count 从 1 开始递增、相邻 Scheduled 相差一个周期。
+func TestEventTickerCountsFires(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	const d = 5 * testTick
+	ticker := w.NewEventTicker(d, WithPeriodMode(FixedRate))
+
+	var prev TickEvent
+	for i := 0; i < 3; i++ {
+		var ev TickEvent
+		select {
+		case ev = <-ticker.C:
+		case <-time.After(200 * time.Millisecond):
+			t.Fatalf("timed out waiting for tick event %d", i+1)
+		}
+		if i == 0 && ev.Count != 1 {
+			t.Fatalf("first event Count = %d, expected 1", ev.Count)
+		}
+		if i > 0 {
+			if ev.Count <= prev.Count {
+				t.Fatalf("event Count = %d after %d, expected increasing", ev.Count, prev.Count)
+			}
+			if got := ev.Scheduled.Sub(prev.Scheduled); got != time.Duration(ev.Count-prev.Count)*d {
+				t.Fatalf("Scheduled advanced %s, expected %s", got, time.Duration(ev.Count-prev.Count)*d)
+			}
+		}
+		if ev.Lateness < 0 {
+			t.Fatalf("Lateness = %s, expected non-negative", ev.Lateness)
+		}
+		prev = ev
+	}
+
+	requireEventually(t, 100*time.Millisecond, ticker.Stop, "event ticker did not stop")
+	assertWheelEmpty(t, w)
+}
@@ -0,0 +1,78 @@
+package timer
+
+// maxGeometryBits 是 WithGeometry 中 rootBits 和 levelBits 的上限, 一层最多 65536 个槽
+const maxGeometryBits = 16
+
+// geometry 是时间轮的层级结构: tv1 有 1<<rootBits 个槽, 之后 levels-1 层各有 1<<levelBits 个槽, 见 WithGeometry。
+// Wheel 和 SlabWheel 共用槽位的计算和 cascade 的顺序, 只有链表的存储方式不同
+type geometry struct {
+	rootBits  uint64
+	levelBits uint64
+	rootMask  uint64
+	levelMask uint64
+	levels    int
+	maxIdx    uint64 //最高层能表示的范围, 超过的 timer 放到 overflow
+}
+
+func newGeometry(rootBits, levelBits uint64, levels int) geometry {
+	g := geometry{rootBits: rootBits, levelBits: levelBits, levels: levels}
+	g.rootMask = 1<<rootBits - 1
+	g.levelMask = 1<<levelBits - 1
+	g.maxIdx = 1 << g.levelShift(levels)
+	return g
+}
+
+// defaultGeometry 是 tv1 256 个槽、tv2..tv5 各 64 个槽的默认层级
+func defaultGeometry() geometry {
+	return newGeometry(tvr_bits, tvn_bits, int(tv_levels))
+}
+
+// levelShift 返回第 level 层(level >= 1)槽位下标在 expires 中的起始 bit
+func (g *geometry) levelShift(level int) uint64 {
+	return g.rootBits + uint64(level-1)*g.levelBits
+}
+
+// levelIndex 返回 jiffies 在第 level 层(level >= 1)的槽位下标
+func (g *geometry) levelIndex(jiffies uint64, level int) uint64 {
+	return (jiffies >> g.levelShift(level)) & g.levelMask
+}
+
+// slotOf 返回当前为 jiffies 时 expires 应该放在第几层的第几个槽, 已经过期的放在 tv1 当前的槽里;
+// overflow 为 true 表示超出所有层级的范围, 先放到 overflow, 等最高层 cascade 时再检查
+func (g *geometry) slotOf(expires, jiffies uint64) (level int, i uint64, overflow bool) {
+	idx := expires - jiffies
+	switch {
+	case idx < 1<<g.rootBits:
+		return 0, expires & g.rootMask, false
+	case int64(idx) < 0:
+		return 0, jiffies & g.rootMask, false
+	case idx < g.maxIdx:
+		level = 1
+		for idx >= 1<<g.levelShift(level+1) {
+			level++
+		}
+		return level, (expires >> g.levelShift(level)) & g.levelMask, false
+	default:
+		return 0, 0, true
+	}
+}
+
+// cascade 在处理 jiffies 的槽之前调用: tv1 转完一圈时从低到高对需要 cascade 的层调用 slot(level, i),
+// 最高层 cascade 之后调用 overflow。某一层的下标不为 0 就说明更高层还不需要 cascade。
+// 返回这个 tick 是否是 tv1 一圈的边界
+func (g *geometry) cascade(jiffies uint64, slot func(level int, i uint64), overflow func()) bool {
+	if jiffies&g.rootMask != 0 {
+		return false
+	}
+	top := g.levels - 1
+	for l := 1; l <= top; l++ {
+		i := g.levelIndex(jiffies, l)
+		slot(l, i)
+		if l == top {
+			overflow()
+		} else if i != 0 {
+			break
+		}
+	}
+	return true
+}
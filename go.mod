@@ -1,5 +1,5 @@
 module github.com/jursonmo/timer
 
-go 1.16
+go 1.18
 
-require go.uber.org/goleak v1.1.12 // indirect
+require go.uber.org/goleak v1.1.12
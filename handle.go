@@ -0,0 +1,116 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Handle 是带 generation 的 WheelTimer 引用。
+// *WheelTimer 被 Release 后会放回 sync.Pool, 可能被别的调用者拿去使用, 旧代码再通过 *WheelTimer Stop/ResetTimer
+// 会操作别人的 timer; 通过 Handle 操作时会先检查 generation, 过期的 handle 返回 ErrReleased。
+//
+//	h := w.NewWheelTimerHandle(d, f)
+//	if ok, err := h.Stop(); ok && err == nil {
+//		h.Release()
+//	}
+type Handle struct {
+	t   *timer
+	gen uint32
+}
+
+// Handle 返回当前 generation 的 handle, 应在创建 timer 后立即获取。
+// WithAutoRelease 下 timer 可能在 NewWheelTimerFunc 返回之前就已经执行完、被回收并分配给别人,
+// 这时取得的是别人的 generation, 应该用 NewWheelTimerHandle
+func (t *timer) Handle() Handle {
+	return Handle{t: t, gen: atomic.LoadUint32(&t.gen)}
+}
+
+func NewWheelTimerHandle(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) Handle {
+	return defaultWheelShard.NewWheelTimerHandle(d, f, arg...)
+}
+
+// NewWheelTimerHandle 和 NewWheelTimerFunc 一样, 但返回加入时间轮之前取得的 Handle,
+// timer 马上到期并被自动回收时 handle 也不会指向复用它的新 timer。加入失败时返回零值 Handle, 所有操作都返回 ErrReleased
+func (w *Wheel) NewWheelTimerHandle(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) Handle {
+	t := w.newTimer(d, 0, f, arg...)
+	h := t.Handle()
+	if w.addTimer(t) {
+		return h
+	}
+
+	return Handle{}
+}
+
+func (t *timer) curGen() uint32 {
+	return atomic.LoadUint32(&t.gen)
+}
+
+// live 只是一次 atomic 读, 修改 timer 的操作需要在持有 w.Lock 时检查, 否则检查之后 timer 仍可能被 Release 并复用
+func (t *timer) live(gen uint32) bool {
+	return atomic.LoadUint32(&t.gen) == gen
+}
+
+func (h Handle) check() error {
+	if h.t == nil || !h.t.live(h.gen) {
+		return ErrReleased
+	}
+	return nil
+}
+
+// result 把带 generation 的操作的结果转换成 Handle 的返回值: 只有 ErrReleased 作为 error 返回
+func result(err error) (bool, error) {
+	if err == ErrReleased {
+		return false, err
+	}
+	return err == nil, nil
+}
+
+// Timer 返回 handle 指向的 WheelTimer, handle 过期时返回 ErrReleased
+func (h Handle) Timer() (*WheelTimer, error) {
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	return h.t, nil
+}
+
+func (h Handle) Stop() (bool, error) {
+	if h.t == nil {
+		return false, ErrReleased
+	}
+	return result(h.t.stop(h.gen))
+}
+
+func (h Handle) ResetTimer(d time.Duration, period time.Duration) (bool, error) {
+	if h.t == nil {
+		return false, ErrReleased
+	}
+	return result(h.t.w.resetTimerE(h.t, h.gen, d, period, false))
+}
+
+// SetPeriodMode 修改周期 timer 的调度方式, 下一次重新调度时生效, handle 过期时返回 ErrReleased
+func (h Handle) SetPeriodMode(mode PeriodMode) error {
+	if h.t == nil {
+		return ErrReleased
+	}
+	return h.t.w.setPeriodMode(h.t, h.gen, mode)
+}
+
+// SetSlack 见 WheelTimer.SetSlack, handle 过期时不生效
+func (h Handle) SetSlack(d time.Duration) {
+	if h.t != nil {
+		h.t.w.setSlack(h.t, h.gen, d)
+	}
+}
+
+func (h Handle) Release() error {
+	if h.t == nil {
+		return ErrReleased
+	}
+	switch err := h.t.tryRelease(h.gen); err {
+	case nil, ErrReleased:
+		return err
+	default:
+		h.t.w.violation(err)
+		return nil
+	}
+}
@@ -0,0 +1,218 @@
+package timer
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestStaleTimerHandleDoesNotStopReusedTimer 测试 Release 之后的旧 handle 不会影响复用的 timer。
+// 功能点：Timer 被 Release 后，旧的 *Timer 上的 Stop/Reset/Release 应失效，不能停止从 pool 复用同一个内部对象的新 timer。
+// 方法：创建、Stop、Release 一个 timer，再创建新 timer，用旧 handle 调用 Stop/Reset/Release，检查新 timer 仍在时间轮中。
+func TestStaleTimerHandleDoesNotStopReusedTimer(t *testing.T) {
+	w := newTestWheel(t, testTick)
+
+	stale := w.NewTimer(time.Second)
+	if !stale.Stop() {
+		t.Fatalf("Stop returned false, expected true")
+	}
+	stale.Release()
+
+	fresh := w.NewTimer(time.Second)
+	if stale.Stop() {
+		t.Fatalf("Stop of released timer returned true, expected false")
+	}
+	if stale.Reset(time.Millisecond) {
+		t.Fatalf("Reset of released timer returned true, expected false")
+	}
+	stale.Release()
+	if got := w.Timers(); got != 1 {
+		t.Fatalf("Timers() = %d after stale operations, expected fresh timer still pending", got)
+	}
+
+	if !fresh.Stop() {
+		t.Fatalf("Stop of fresh timer returned false, expected true")
+	}
+	fresh.Release()
+	assertWheelEmpty(t, w)
+}
+
+// TestHandleReturnsErrReleased 测试 WheelTimer.Handle 的 generation 检查。
+// 功能点：通过 Handle 操作已经 Release 的 timer 应返回 ErrReleased；重复 Release 不应把同一个对象放回 pool 两次。
+// 方法：获取 handle 后 Stop、Release，再对 handle 调用 Stop/ResetTimer/Release/Timer，检查都返回 ErrReleased。
+func TestHandleReturnsErrReleased(t *testing.T) {
+	w := newTestWheel(t, testTick, WithLogger(benchDiscardLogger{}))
+	h := w.NewWheelTimerHandle(time.Second, func(time.Time, ...interface{}) {})
+
+	if ok, err := h.Stop(); !ok || err != nil {
+		t.Fatalf("Stop() = %v, %v, expected true, nil", ok, err)
+	}
+	if err := h.Release(); err != nil {
+		t.Fatalf("Release() = %v, expected nil", err)
+	}
+
+	if _, err := h.Stop(); err != ErrReleased {
+		t.Fatalf("Stop() after Release err = %v, expected ErrReleased", err)
+	}
+	if _, err := h.ResetTimer(time.Millisecond, 0); err != ErrReleased {
+		t.Fatalf("ResetTimer() after Release err = %v, expected ErrReleased", err)
+	}
+	if err := h.Release(); err != ErrReleased {
+		t.Fatalf("Release() after Release err = %v, expected ErrReleased", err)
+	}
+	if _, err := h.Timer(); err != ErrReleased {
+		t.Fatalf("Timer() after Release err = %v, expected ErrReleased", err)
+	}
+	assertWheelEmpty(t, w)
+}
+
+// TestAutoReleaseRecyclesOneShotTimers 测试自动回收模式。
+// 功能点：WithAutoRelease 下一次性 timer 在 callback 执行完或 Stop 成功后由 wheel 放回 pool，旧 handle 失效，
+// 调用者的 Release 被忽略；回收的对象会被后续 timer 复用。
+// 方法：分别走 callback 执行和 Stop 两条路径，检查 handle 变为 ErrReleased，再创建新 timer 检查 PoolNewCount 没有增加。
+func TestAutoReleaseRecyclesOneShotTimers(t *testing.T) {
+	w := newTestWheel(t, testTick, WithAutoRelease(), WithTimerPool(NewTimerSyncPool()))
+
+	done := make(chan struct{}, 1)
+	h := w.NewWheelTimerHandle(0, func(time.Time, ...interface{}) {
+		done <- struct{}{}
+	})
+	waitStruct(t, done, 200*time.Millisecond, "auto release callback")
+	requireEventually(t, 100*time.Millisecond, func() bool {
+		_, err := h.Timer()
+		return err == ErrReleased
+	}, "fired timer was not released by the wheel")
+
+	stopped := w.AfterFunc(time.Second, func() {})
+	if !stopped.Stop() {
+		t.Fatalf("Stop returned false, expected true")
+	}
+	if _, err := stopped.Handle().Timer(); err != ErrReleased {
+		t.Fatalf("handle after Stop err = %v, expected ErrReleased", err)
+	}
+	stopped.Release()
+
+	//timerdebug 模式下 timer 不复用, race 模式下 sync.Pool 会随机丢弃对象
+	if timerDebug || raceEnabled {
+		return
+	}
+	before := w.PoolNewCount()
+	next := w.NewTimer(time.Second)
+	if got := w.PoolNewCount(); got != before {
+		t.Fatalf("PoolNewCount = %d after auto release, expected reuse and %d", got, before)
+	}
+	next.Stop()
+	assertWheelEmpty(t, w)
+}
+
+// lifoPool 是确定性复用的 timer pool: Get 总是返回最近一次 Put 的 timer
+type lifoPool struct {
+	sync.Mutex
+	free []*WheelTimer
+}
+
+func (p *lifoPool) Get() *WheelTimer {
+	p.Lock()
+	defer p.Unlock()
+	if n := len(p.free); n > 0 {
+		t := p.free[n-1]
+		p.free = p.free[:n-1]
+		return t
+	}
+	return new(WheelTimer)
+}
+
+func (p *lifoPool) Put(t *WheelTimer) {
+	p.Lock()
+	p.free = append(p.free, t)
+	p.Unlock()
+}
+
+// TestStaleGenerationCheckedUnderLock 测试 generation 在修改 timer 的操作内部检查。
+// 功能点：handle 检查通过之后 timer 被 Release 并复用时，Stop/Reset/Release/SetSlack/SetPeriodMode 在持有锁(无锁模式下和 qs 的 CAS 一起)
+// 检查 generation，返回 ErrReleased，不会操作到新的调用者的 timer。
+// 方法：用确定性复用的 pool，旧 timer Stop、Release 之后创建新 timer 拿到同一个对象，
+// 跳过外层的检查直接用旧 generation 调用内部的 stop/resetTimerE/tryRelease/setSlack/setPeriodMode，检查新 timer 仍在时间轮中。
+func TestStaleGenerationCheckedUnderLock(t *testing.T) {
+	if timerDebug {
+		t.Skip("timerdebug 模式下 timer 不复用")
+	}
+	for _, queued := range []bool{false, true} {
+		opts := []Option{WithTimerPool(&lifoPool{})}
+		if queued {
+			opts = append(opts, WithQueuedAdd())
+		}
+		w := newIdleTestWheel(t, opts...)
+
+		stale := w.NewTimer(time.Hour)
+		r, gen := stale.r, stale.gen
+		if !stale.Stop() {
+			t.Fatalf("queued=%v: Stop() = false, expected true", queued)
+		}
+		stale.Release()
+		fresh := w.NewTimer(time.Hour)
+		if fresh.r != r {
+			t.Fatalf("queued=%v: fresh timer did not reuse the released object", queued)
+		}
+
+		if err := r.stop(gen); err != ErrReleased {
+			t.Fatalf("queued=%v: stale stop err = %v, expected ErrReleased", queued, err)
+		}
+		if err := w.resetTimerE(r, gen, time.Millisecond, 0, false); err != ErrReleased {
+			t.Fatalf("queued=%v: stale reset err = %v, expected ErrReleased", queued, err)
+		}
+		if err := r.tryRelease(gen); err != ErrReleased {
+			t.Fatalf("queued=%v: stale release err = %v, expected ErrReleased", queued, err)
+		}
+		w.setSlack(r, gen, time.Hour)
+		if r.slackSet {
+			t.Fatalf("queued=%v: stale SetSlack changed the fresh timer", queued)
+		}
+		if err := w.setPeriodMode(r, gen, FixedRate); err != ErrReleased || r.periodMode() != FixedDelay {
+			t.Fatalf("queued=%v: stale SetPeriodMode err = %v, mode = %v, expected ErrReleased and FixedDelay", queued, err, r.periodMode())
+		}
+		if got := w.Timers(); got != 1 {
+			t.Fatalf("queued=%v: Timers() = %d after stale operations, expected fresh timer still pending", queued, got)
+		}
+		if !fresh.Stop() {
+			t.Fatalf("queued=%v: Stop of fresh timer returned false, expected true", queued)
+		}
+		fresh.Release()
+		assertWheelEmpty(t, w)
+	}
+}
+
+// TestNewWheelTimerHandleBeforeAutoRelease 测试 NewWheelTimerHandle 在加入时间轮之前取得 generation。
+// 功能点：自动回收模式下 timer 执行完马上被回收并分配给新的 timer，之前返回的 handle 仍然是旧的 generation，
+// 不会停止新的 timer。
+// 方法：用确定性复用的 pool，创建马上到期的 timer 并手动推进时间轮，等它执行完被回收后创建新 timer 拿到同一个对象，
+// 再用旧 handle Stop，检查返回 ErrReleased 并且新 timer 仍在时间轮中。
+func TestNewWheelTimerHandleBeforeAutoRelease(t *testing.T) {
+	if timerDebug {
+		t.Skip("timerdebug 模式下 timer 不复用")
+	}
+	w := newIdleTestWheel(t, WithAutoRelease(), WithTimerPool(&lifoPool{}))
+	h := w.NewWheelTimerHandle(0, func(time.Time, ...interface{}) {})
+	r, err := h.Timer()
+	if err != nil {
+		t.Fatalf("Timer() err = %v, expected nil", err)
+	}
+	w.onTick()
+	requireEventually(t, time.Second, func() bool {
+		_, err := h.Timer()
+		return err == ErrReleased
+	}, "fired timer was not released by the wheel")
+
+	next := w.NewWheelTimerFunc(time.Hour, func(time.Time, ...interface{}) {})
+	if next != r {
+		t.Fatalf("new timer did not reuse the released object")
+	}
+	if ok, err := h.Stop(); ok || err != ErrReleased {
+		t.Fatalf("Stop() with old handle = %v, %v, expected false, ErrReleased", ok, err)
+	}
+	if got := w.Timers(); got != 1 {
+		t.Fatalf("Timers() = %d, expected the new timer still pending", got)
+	}
+	next.Stop()
+	assertWheelEmpty(t, w)
+}
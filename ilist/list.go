@@ -8,28 +8,39 @@ package ilist
 // Linker is the interface that objects must implement if they want to be added
 // to and/or removed from List objects.
 //
-// N.B. When substituted in a template instantiation, Linker doesn't need to
-// be an interface, and in most cases won't be.
-type Linker interface {
-	Next() Linker
-	Prev() Linker
-	SetNext(Linker)
-	SetPrev(Linker)
+// T is the element type itself (usually a pointer to a struct embedding
+// Entry[T]), so traversals return the concrete type and need no type
+// assertions.
+type Linker[T any] interface {
+	Next() T
+	Prev() T
+	SetNext(T)
+	SetPrev(T)
 	Reset() //add
 }
 
+// Element is the constraint for the elements of a List: a comparable Linker
+// whose zero value (nil) marks the end of the list.
+type Element[T any] interface {
+	comparable
+	Linker[T]
+}
+
 // List is an intrusive list. Entries can be added to or removed from the list
-// in O(1) time and with no additional memory allocations.
+// in O(1) time and with no additional memory allocations. Whole lists can be
+// moved with PushBackList in O(1) time as well.
 //
 // The zero value for List is an empty list ready to use.
 //
-// To iterate over a list (where l is a List):
-//      for e := l.Front(); e != nil; e = e.Next() {
-// 		// do something with e.
-//      }
-type List struct {
-	head Linker
-	tail Linker
+// To iterate over a list (where l is a List[*T]):
+//
+//	for e := l.Front(); e != nil; e = e.Next() {
+//		// do something with e.
+//	}
+type List[T Element[T]] struct {
+	head T
+	tail T
+	len  int
 }
 
 /* add by mo:
@@ -43,154 +54,180 @@ List              e               e               e
         ------------------------------------------>|
 */
 // Reset resets list l to the empty state.
-func (l *List) Reset() {
-	l.head = nil
-	l.tail = nil
+func (l *List[T]) Reset() {
+	var zero T
+	l.head = zero
+	l.tail = zero
+	l.len = 0
 }
 
 // Empty returns true iff the list is empty.
-func (l *List) Empty() bool {
-	return l.head == nil
+func (l *List[T]) Empty() bool {
+	var zero T
+	return l.head == zero
+}
+
+// Len returns the number of elements of list l in O(1) time.
+func (l *List[T]) Len() int {
+	return l.len
 }
 
 // Front returns the first element of list l or nil.
-func (l *List) Front() Linker {
+func (l *List[T]) Front() T {
 	return l.head
 }
 
 // Back returns the last element of list l or nil.
-func (l *List) Back() Linker {
+func (l *List[T]) Back() T {
 	return l.tail
 }
 
 // PushFront inserts the element e at the front of list l.
-func (l *List) PushFront(e Linker) {
+func (l *List[T]) PushFront(e T) {
+	var zero T
 	e.SetNext(l.head)
-	e.SetPrev(nil)
+	e.SetPrev(zero)
 
-	if l.head != nil {
+	if l.head != zero {
 		l.head.SetPrev(e)
 	} else {
 		l.tail = e
 	}
 
 	l.head = e
+	l.len++
 }
 
 // PushBack inserts the element e at the back of list l.
-func (l *List) PushBack(e Linker) {
-	e.SetNext(nil)
+func (l *List[T]) PushBack(e T) {
+	var zero T
+	e.SetNext(zero)
 	e.SetPrev(l.tail)
 
-	if l.tail != nil {
+	if l.tail != zero {
 		l.tail.SetNext(e)
 	} else {
 		l.head = e
 	}
 
 	l.tail = e
+	l.len++
 }
 
-// PushBackList inserts list m at the end of list l, emptying m.
-func (l *List) PushBackList(m *List) {
-	if l.head == nil {
+// PushBackList inserts list m at the end of list l, emptying m. It only
+// relinks the two ends, the elements of m are not visited.
+func (l *List[T]) PushBackList(m *List[T]) {
+	var zero T
+	if l.head == zero {
 		l.head = m.head
 		l.tail = m.tail
-	} else if m.head != nil {
+	} else if m.head != zero {
 		l.tail.SetNext(m.head)
 		m.head.SetPrev(l.tail)
 
 		l.tail = m.tail
 	}
+	l.len += m.len
 
-	m.head = nil
-	m.tail = nil
+	m.Reset()
 }
 
 // InsertAfter inserts e after b.
-func (l *List) InsertAfter(b, e Linker) {
+func (l *List[T]) InsertAfter(b, e T) {
+	var zero T
 	a := b.Next()
 	e.SetNext(a)
 	e.SetPrev(b)
 	b.SetNext(e)
 
-	if a != nil {
+	if a != zero {
 		a.SetPrev(e)
 	} else {
 		l.tail = e
 	}
+	l.len++
 }
 
 // InsertBefore inserts e before a.
-func (l *List) InsertBefore(a, e Linker) {
+func (l *List[T]) InsertBefore(a, e T) {
+	var zero T
 	b := a.Prev()
 	e.SetNext(a)
 	e.SetPrev(b)
 	a.SetPrev(e)
 
-	if b != nil {
+	if b != zero {
 		b.SetNext(e)
 	} else {
 		l.head = e
 	}
+	l.len++
 }
 
 // Remove removes e from l.
-func (l *List) Remove(e Linker) {
+func (l *List[T]) Remove(e T) {
+	var zero T
 	prev := e.Prev()
 	next := e.Next()
 
-	if prev != nil {
+	if prev != zero {
 		prev.SetNext(next)
 	} else {
 		l.head = next
 	}
 
-	if next != nil {
+	if next != zero {
 		next.SetPrev(prev)
 	} else {
 		l.tail = prev
 	}
+	l.len--
 }
 
 // Entry is a default implementation of Linker. Users can add anonymous fields
 // of this type to their structs to make them automatically implement the
-// methods needed by List.
-type Entry struct {
-	next Linker
-	prev Linker
+// methods needed by List, e.g.
+//
+//	type timer struct {
+//		ilist.Entry[*timer]
+//		...
+//	}
+type Entry[T comparable] struct {
+	next T
+	prev T
 }
 
 // Next returns the entry that follows e in the list.
-func (e *Entry) Next() Linker {
+func (e *Entry[T]) Next() T {
 	return e.next
 }
 
 // Prev returns the entry that precedes e in the list.
-func (e *Entry) Prev() Linker {
+func (e *Entry[T]) Prev() T {
 	return e.prev
 }
 
 // SetNext assigns 'entry' as the entry that follows e in the list.
-func (e *Entry) SetNext(entry Linker) {
+func (e *Entry[T]) SetNext(entry T) {
 	e.next = entry
 }
 
 // SetPrev assigns 'entry' as the entry that precedes e in the list.
-func (e *Entry) SetPrev(entry Linker) {
+func (e *Entry[T]) SetPrev(entry T) {
 	e.prev = entry
 }
 
-func (e *Entry) InitEntry() {
-	e.next = nil
-	e.prev = nil
+func (e *Entry[T]) InitEntry() {
+	e.Reset()
 }
 
-func (e *Entry) IsInit() bool {
-	return e.next == nil && e.prev == nil
+func (e *Entry[T]) IsInit() bool {
+	var zero T
+	return e.next == zero && e.prev == zero
 }
 
-func (e *Entry) Reset() {
-	e.next = nil
-	e.prev = nil
+func (e *Entry[T]) Reset() {
+	var zero T
+	e.next = zero
+	e.prev = zero
 }
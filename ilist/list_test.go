@@ -0,0 +1,214 @@
+package ilist
+
+import "testing"
+
+type node struct {
+	Entry[*node]
+	v int
+}
+
+func values(l *List[*node]) []int {
+	var vs []int
+	for e := l.Front(); e != nil; e = e.Next() {
+		vs = append(vs, e.v)
+	}
+	return vs
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestListOps 测试链表的基本操作。
+// 功能点：PushBack/PushFront/InsertAfter/InsertBefore/Remove 之后元素的顺序、Len、Front/Back 都正确。
+// 方法：依次做各种插入和删除，每一步检查正序遍历的结果和 Len。
+func TestListOps(t *testing.T) {
+	var l List[*node]
+	if !l.Empty() || l.Len() != 0 || l.Front() != nil || l.Back() != nil {
+		t.Fatal("zero List is not empty")
+	}
+	ns := make([]*node, 5)
+	for i := range ns {
+		ns[i] = &node{v: i}
+	}
+	l.PushBack(ns[1])
+	l.PushBack(ns[3])
+	l.PushFront(ns[0])
+	l.InsertAfter(ns[1], ns[2])
+	l.InsertBefore(ns[0], ns[4])
+	if got, want := values(&l), []int{4, 0, 1, 2, 3}; !equal(got, want) || l.Len() != 5 {
+		t.Fatalf("list = %v (Len %d), expected %v", got, l.Len(), want)
+	}
+
+	l.Remove(ns[4])
+	l.Remove(ns[3])
+	l.Remove(ns[1])
+	if got, want := values(&l), []int{0, 2}; !equal(got, want) || l.Len() != 2 {
+		t.Fatalf("list = %v (Len %d), expected %v", got, l.Len(), want)
+	}
+	if l.Front() != ns[0] || l.Back() != ns[2] || ns[0].Prev() != nil || ns[2].Next() != nil {
+		t.Fatal("Front/Back links broken after Remove")
+	}
+	l.Remove(ns[0])
+	l.Remove(ns[2])
+	if !l.Empty() || l.Len() != 0 {
+		t.Fatalf("list = %v (Len %d), expected empty", values(&l), l.Len())
+	}
+}
+
+// TestPushBackList 测试整个链表的拼接。
+// 功能点：PushBackList 把 m 接到 l 的末尾并清空 m，Len 相加；l 或 m 为空时也正确。
+// 方法：分别拼接空链表和非空链表，检查遍历结果、Len 和 m 是否为空，以及拼接处的 Prev 链接。
+func TestPushBackList(t *testing.T) {
+	var l, m List[*node]
+	for i := 0; i < 3; i++ {
+		m.PushBack(&node{v: i})
+	}
+	l.PushBackList(&m)
+	if got, want := values(&l), []int{0, 1, 2}; !equal(got, want) || l.Len() != 3 {
+		t.Fatalf("list = %v (Len %d), expected %v", got, l.Len(), want)
+	}
+	if !m.Empty() || m.Len() != 0 {
+		t.Fatal("m not empty after PushBackList")
+	}
+
+	l.PushBackList(&m)
+	if l.Len() != 3 {
+		t.Fatalf("Len() after appending empty list = %d, expected 3", l.Len())
+	}
+
+	for i := 3; i < 5; i++ {
+		m.PushBack(&node{v: i})
+	}
+	joint := m.Front()
+	l.PushBackList(&m)
+	if got, want := values(&l), []int{0, 1, 2, 3, 4}; !equal(got, want) || l.Len() != 5 {
+		t.Fatalf("list = %v (Len %d), expected %v", got, l.Len(), want)
+	}
+	if joint.Prev().v != 2 || l.Back().v != 4 {
+		t.Fatal("links broken at the joint of PushBackList")
+	}
+}
+
+// ifaceLinker/ifaceList 是泛型之前基于接口的实现, 只用来在 benchmark 中对比
+type ifaceLinker interface {
+	Next() ifaceLinker
+	Prev() ifaceLinker
+	SetNext(ifaceLinker)
+	SetPrev(ifaceLinker)
+}
+
+type ifaceList struct {
+	head ifaceLinker
+	tail ifaceLinker
+}
+
+func (l *ifaceList) PushBack(e ifaceLinker) {
+	e.SetNext(nil)
+	e.SetPrev(l.tail)
+	if l.tail != nil {
+		l.tail.SetNext(e)
+	} else {
+		l.head = e
+	}
+	l.tail = e
+}
+
+func (l *ifaceList) Remove(e ifaceLinker) {
+	prev := e.Prev()
+	next := e.Next()
+	if prev != nil {
+		prev.SetNext(next)
+	} else {
+		l.head = next
+	}
+	if next != nil {
+		next.SetPrev(prev)
+	} else {
+		l.tail = prev
+	}
+}
+
+type ifaceNode struct {
+	next, prev ifaceLinker
+	v          int
+}
+
+func (e *ifaceNode) Next() ifaceLinker         { return e.next }
+func (e *ifaceNode) Prev() ifaceLinker         { return e.prev }
+func (e *ifaceNode) SetNext(entry ifaceLinker) { e.next = entry }
+func (e *ifaceNode) SetPrev(entry ifaceLinker) { e.prev = entry }
+
+const benchNodes = 1 << 12
+
+// BenchmarkTraverse 对比遍历链表并读取元素字段的开销: 泛型链表直接得到 *node,
+// 接口链表每个元素都需要接口调用和类型断言。
+func BenchmarkTraverse(b *testing.B) {
+	b.Run("generic", func(b *testing.B) {
+		var l List[*node]
+		for i := 0; i < benchNodes; i++ {
+			l.PushBack(&node{v: i})
+		}
+		b.ResetTimer()
+		sum := 0
+		for i := 0; i < b.N; i++ {
+			for e := l.Front(); e != nil; e = e.Next() {
+				sum += e.v
+			}
+		}
+		_ = sum
+	})
+	b.Run("interface", func(b *testing.B) {
+		var l ifaceList
+		for i := 0; i < benchNodes; i++ {
+			l.PushBack(&ifaceNode{v: i})
+		}
+		b.ResetTimer()
+		sum := 0
+		for i := 0; i < b.N; i++ {
+			for e := l.head; e != nil; e = e.Next() {
+				sum += e.(*ifaceNode).v
+			}
+		}
+		_ = sum
+	})
+}
+
+// BenchmarkMoveList 对比把一个链表的所有元素移到另一个链表的开销: 泛型链表用 PushBackList O(1) 拼接,
+// 接口链表逐个 Remove/PushBack。
+func BenchmarkMoveList(b *testing.B) {
+	b.Run("generic-splice", func(b *testing.B) {
+		var l, m List[*node]
+		for i := 0; i < benchNodes; i++ {
+			l.PushBack(&node{v: i})
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.PushBackList(&l)
+			l, m = m, l
+		}
+	})
+	b.Run("interface-per-element", func(b *testing.B) {
+		var l, m ifaceList
+		for i := 0; i < benchNodes; i++ {
+			l.PushBack(&ifaceNode{v: i})
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for l.head != nil {
+				e := l.head
+				l.Remove(e)
+				m.PushBack(e)
+			}
+			l, m = m, l
+		}
+	})
+}
@@ -0,0 +1,342 @@
+package timer
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// LimitPolicy 决定时间轮或者 Group 中的 timer 数量达到上限时怎么处理新加入的 timer
+type LimitPolicy int
+
+const (
+	RejectNew      LimitPolicy = iota //拒绝新的 timer, 返回 ErrTooManyTimers(默认)
+	EvictEarliest                     //取消最早到期的 timer, 给新的 timer 腾位置
+	EvictLatest                       //取消最晚到期的 timer, 给新的 timer 腾位置
+	BlockUntilFree                    //阻塞到有 timer 到期或者被 Stop, 不能在 callback 中使用, 见 WithLimitPolicy
+)
+
+func (p LimitPolicy) String() string {
+	switch p {
+	case RejectNew:
+		return "RejectNew"
+	case EvictEarliest:
+		return "EvictEarliest"
+	case EvictLatest:
+		return "EvictLatest"
+	case BlockUntilFree:
+		return "BlockUntilFree"
+	}
+	return "LimitPolicy(?)"
+}
+
+// WithMaxTimers 限制时间轮中还没有执行的 timer 数量, 防止泄漏 timer 的 bug 把内存耗尽, n <= 0 表示不限制。
+// 达到上限时按 WithLimitPolicy 处理, 默认 RejectNew; 新建和 Reset 都会检查, 周期 timer 重新加入不检查
+func WithMaxTimers(n int) Option {
+	return func(w *Wheel) {
+		w.maxTimers = n
+	}
+}
+
+// WithLimitPolicy 设置 WithMaxTimers 达到上限时的处理方式。
+// BlockUntilFree 等的是别的 goroutine 里 timer 到期或者被 Stop, 不能在 timer 的 callback 中阻塞地加入 timer:
+// 在 ManualWheel/SimWheel 中, 或者在执行 callback 的派发 goroutine 中(NewWheelTimerFunc 之类的 callback, 包括精确模式)
+// 需要等待时不阻塞, 直接返回 ErrWouldBlock 并计入 Stats().Rejected。
+// AfterFunc/TickFunc 的 f 在单独的 goroutine 中执行, 不会卡住时间轮, 可以阻塞
+func WithLimitPolicy(p LimitPolicy) Option {
+	return func(w *Wheel) {
+		w.limitPolicy = p
+		if p == BlockUntilFree {
+			w.blockable = 1
+		}
+	}
+}
+
+// Group 是共享同一个时间轮的一组 timer, 有自己的数量配额, 一个 group 的 timer 泄漏不会占满整个时间轮
+type Group struct {
+	w       *Wheel
+	quota   int
+	policy  LimitPolicy
+	n       int  //group 中还没有执行的 timer 数量, 由 w.Lock 保护
+	stopped bool //已经 Stop, 由 w.Lock 保护
+
+	timers map[*timer]struct{} //group 中还在时间轮里的 timer, 淘汰和 Stop 时不需要遍历整个时间轮, 由 w.Lock 保护
+
+	ctx    context.Context //group 中 ctx callback 的 ctx 的父 ctx, Stop 时 cancel
+	cancel context.CancelFunc
+}
+
+// NewGroup 创建配额为 quota 的 Group, quota <= 0 表示只受时间轮的上限限制。
+// policy 为 BlockUntilFree 时和 WithLimitPolicy 一样不能在 callback 中阻塞地加入 timer
+func (w *Wheel) NewGroup(quota int, policy LimitPolicy) *Group {
+	g := &Group{w: w, quota: quota, policy: policy, timers: make(map[*timer]struct{})}
+	g.ctx, g.cancel = context.WithCancel(w.ctx)
+	if policy == BlockUntilFree {
+		atomic.StoreInt32(&w.blockable, 1)
+	}
+	return g
+}
+
+// Stop 取消整个 group: 还在时间轮中的 timer 都被 Stop(自动回收模式下一次性 timer 放回 pool, 和 Stop 一样),
+// 正在执行的 ctx callback 的 ctx 被 cancel, 正在执行的周期 timer 执行完后不再加入时间轮,
+// 之后 group 的 Try* 接口返回 ErrGroupStopped。返回被 Stop 的 timer 数量, 重复调用返回 0
+func (g *Group) Stop() int {
+	w := g.w
+	w.Lock()
+	w.drainLocked()
+	if g.stopped {
+		w.Unlock()
+		return 0
+	}
+	g.stopped = true
+	stopped := make([]*timer, 0, len(g.timers))
+	for t := range g.timers {
+		stopped = append(stopped, t)
+	}
+	for _, t := range stopped {
+		w.delLocked(t)
+	}
+	w.Unlock()
+	g.cancel()
+	w.releaseEvicted(stopped)
+	return len(stopped)
+}
+
+func (g *Group) Timers() int {
+	g.w.Lock()
+	defer g.w.Unlock()
+	return g.n
+}
+
+func (g *Group) TryNewTimer(d time.Duration) (*Timer, error) {
+	t := g.w.chanTimer(d)
+	t.r.group = g
+	if err := g.w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (g *Group) TryAfterFunc(d time.Duration, f func()) (*Timer, error) {
+	t := g.w.afterFunc(d, f)
+	t.r.group = g
+	if err := g.w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (g *Group) TryNewTicker(d time.Duration, opts ...TickerOption) (*Ticker, error) {
+	t := g.w.chanTicker(d, opts...)
+	t.r.group = g
+	if err := g.w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (g *Group) TryNewWheelTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) (*WheelTimer, error) {
+	t := g.w.newTimer(d, 0, f, arg...)
+	t.group = g
+	if err := g.w.start(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// TryNewWheelTimerCtxFunc 见 Wheel.NewWheelTimerCtxFunc, group Stop 时执行中的 ctx 也会被 cancel
+func (g *Group) TryNewWheelTimerCtxFunc(d time.Duration, f func(context.Context, time.Time, ...interface{}), arg ...interface{}) (*WheelTimer, error) {
+	t := g.w.newTimer(d, 0, nil, arg...)
+	t.ctxF = f
+	t.group = g
+	if err := g.w.start(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// track/untrack 维护时间轮和 group 的 timer 数量, 调用者持有 w.Lock
+func (w *Wheel) track(t *timer) {
+	w.timers++
+	if t.group != nil {
+		t.group.n++
+		t.group.timers[t] = struct{}{}
+	}
+}
+
+func (w *Wheel) untrack(t *timer) {
+	w.timers--
+	if t.group != nil {
+		t.group.n--
+		delete(t.group.timers, t)
+	}
+	if w.blocked > 0 && w.freed != nil {
+		close(w.freed)
+		w.freed = nil
+	}
+}
+
+// admit 检查时间轮和 t 所属 group 的上限, 调用者持有 w.Lock; BlockUntilFree 等待时会暂时释放锁。
+// 返回被淘汰的 timer, 由调用者在释放锁之后处理
+func (w *Wheel) admit(t *timer) (evicted []*timer, err error) {
+	for {
+		if t.group != nil && t.group.stopped {
+			return evicted, ErrGroupStopped
+		}
+		var g *Group
+		var policy LimitPolicy
+		switch {
+		case w.maxTimers > 0 && w.timers >= w.maxTimers:
+			policy = w.limitPolicy
+		case t.group != nil && t.group.quota > 0 && t.group.n >= t.group.quota:
+			g, policy = t.group, t.group.policy
+		default:
+			return evicted, nil
+		}
+
+		switch policy {
+		case EvictEarliest, EvictLatest:
+			if v := w.victim(g, policy == EvictLatest); v != nil {
+				w.delLocked(v)
+				atomic.AddUint64(&w.evicted, 1)
+				evicted = append(evicted, v)
+				continue
+			}
+		case BlockUntilFree:
+			if err := w.waitFree(); err != nil {
+				return evicted, err
+			}
+			continue
+		}
+		atomic.AddUint64(&w.rejected, 1)
+		return evicted, ErrTooManyTimers
+	}
+}
+
+// waitFree 等到有 timer 离开时间轮, 调用者持有 w.Lock。
+// ManualWheel/SimWheel 的 Unlock 什么都不做, 派发 goroutine 阻塞时它后面的 callback 也不会执行, 这两种情况返回 ErrWouldBlock
+func (w *Wheel) waitFree() error {
+	select {
+	case <-w.quit:
+		return ErrWheelStopped
+	default:
+	}
+	if w.single || w.inDispatch() {
+		atomic.AddUint64(&w.rejected, 1)
+		return ErrWouldBlock
+	}
+	if w.freed == nil {
+		w.freed = make(chan struct{})
+	}
+	freed := w.freed
+	w.blocked++
+	w.Unlock()
+	select {
+	case <-freed:
+	case <-w.quit:
+	}
+	w.Lock()
+	w.blocked--
+	return nil
+}
+
+// inDispatch 返回当前 goroutine 是否正在执行 runList
+func (w *Wheel) inDispatch() bool {
+	if atomic.LoadInt32(&w.blockable) == 0 {
+		return false
+	}
+	_, ok := w.dispatchers.Load(goroutineID())
+	return ok
+}
+
+// victim 找到 g(nil 表示整个时间轮)中最早或者最晚到期的 timer, 调用者持有 w.Lock。
+// 整个时间轮只看每一层按时间顺序第一个(最晚时是最后一个)非空的槽, 高层的槽和 overflow/parked 用缓存的 bounds,
+// precise 队列按 deadline 排序, 均摊 O(层数); group 只遍历 group 自己的 timer
+func (w *Wheel) victim(g *Group, latest bool) *timer {
+	var v *timer
+	consider := func(t *timer) {
+		if t != nil && (v == nil || (latest && t.expires > v.expires) || (!latest && t.expires < v.expires)) {
+			v = t
+		}
+	}
+	if g != nil {
+		for t := range g.timers {
+			consider(t)
+		}
+		return v
+	}
+	pick := func(b *bounds, l *timerList) {
+		min, max := b.get(l)
+		if latest {
+			consider(max)
+		} else {
+			consider(min)
+		}
+	}
+
+	//tv1 一个槽里的 timer 在同一个 tick 执行
+	if latest {
+		if l := w.lastRoot(); l != nil {
+			consider(l.Back())
+		}
+	} else if j, ok := w.firstRoot(); ok {
+		consider(w.tv[0][j&w.rootMask].Front())
+	}
+	for level := 1; level < len(w.tv); level++ {
+		var l *timerList
+		if latest {
+			l = w.lastSlot(level)
+		} else {
+			l, _ = w.firstSlot(level)
+		}
+		if l != nil {
+			pick(w.slotBounds(level, l), l)
+		}
+	}
+	pick(&w.overflowBounds, &w.overflow)
+	pick(&w.parkedBounds, &w.parked)
+	if latest {
+		consider(w.precise.Back())
+	} else {
+		consider(w.precise.Front())
+	}
+	return v
+}
+
+// lastRoot 返回 tv1 中按时间顺序最后一个非空的槽, 当前 jiffies 的槽排在最前面
+func (w *Wheel) lastRoot() *timerList {
+	cur := w.jiffies & w.rootMask
+	i, ok := w.occ[0].prev((cur-1)&w.rootMask, w.rootMask+1)
+	if !ok {
+		return nil
+	}
+	return &w.tv[0][i]
+}
+
+// lastSlot 返回第 level 层(level >= 1)按时间顺序最后一个非空的槽, 顺序和 firstSlot 一样
+func (w *Wheel) lastSlot(level int) *timerList {
+	shift := w.levelShift(level)
+	c := w.jiffies >> shift
+	k := uint64(1)
+	if w.jiffies&(1<<shift-1) == 0 {
+		k = 0
+	}
+	from := (c + k) & w.levelMask
+	i, ok := w.occ[level].prev((from-1)&w.levelMask, w.levelMask+1)
+	if !ok {
+		return nil
+	}
+	return &w.tv[level][i]
+}
+
+// releaseEvicted 自动回收模式下把被淘汰的一次性 timer 放回 pool, 和 TryStop 一样
+func (w *Wheel) releaseEvicted(evicted []*timer) {
+	if !w.autoRelease {
+		return
+	}
+	for _, t := range evicted {
+		if t.period == 0 {
+			w.releaseTimer(t, t.curGen())
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package timer
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestMaxTimersReject 测试 WithMaxTimers 默认的拒绝策略。
+// 功能点：达到上限后 TryXXX 返回 ErrTooManyTimers，普通接口返回 nil，拒绝次数计入 Stats().Rejected；有 timer 被 Stop 后可以再加入。
+// 方法：上限为 2 的时间轮上创建 3 个 timer，检查返回值和统计。
+func TestMaxTimersReject(t *testing.T) {
+	w := newIdleTestWheel(t, WithMaxTimers(2))
+	a, err := w.TryNewTimer(time.Hour)
+	if err != nil {
+		t.Fatalf("TryNewTimer() err = %v, expected nil", err)
+	}
+	if _, err := w.TryNewTimer(time.Hour); err != nil {
+		t.Fatalf("TryNewTimer() err = %v, expected nil", err)
+	}
+	if tm, err := w.TryNewTimer(time.Hour); tm != nil || err != ErrTooManyTimers {
+		t.Fatalf("TryNewTimer() over limit = %v, %v, expected nil, ErrTooManyTimers", tm, err)
+	}
+	if wt := w.NewWheelTimerFunc(time.Hour, func(time.Time, ...interface{}) {}); wt != nil {
+		t.Fatal("NewWheelTimerFunc() over limit returned timer, expected nil")
+	}
+	if s := w.Stats(); s.Rejected != 2 || s.Timers != 2 {
+		t.Fatalf("Stats() = %+v, expected Rejected 2, Timers 2", s)
+	}
+
+	a.Stop()
+	if _, err := w.TryNewTimer(time.Hour); err != nil {
+		t.Fatalf("TryNewTimer() after Stop err = %v, expected nil", err)
+	}
+}
+
+// TestMaxTimersEvict 测试淘汰策略。
+// 功能点：EvictEarliest 取消最早到期的 timer，EvictLatest 取消最晚到期的 timer，淘汰次数计入 Stats().Evicted。
+// 方法：上限为 2 时依次加入 1h、2h 的 timer，再加入第 3 个，用 TryRelease 判断哪个 timer 已经不在时间轮中。
+func TestMaxTimersEvict(t *testing.T) {
+	for _, tc := range []struct {
+		policy    LimitPolicy
+		wantEarly bool
+	}{
+		{EvictEarliest, true},
+		{EvictLatest, false},
+	} {
+		w := newIdleTestWheel(t, WithMaxTimers(2), WithLimitPolicy(tc.policy))
+		noop := func(time.Time, ...interface{}) {}
+		early := w.NewWheelTimerFunc(time.Hour, noop)
+		late := w.NewWheelTimerFunc(2*time.Hour, noop)
+		if _, err := w.TryNewWheelTimerFunc(90*time.Minute, noop); err != nil {
+			t.Fatalf("%v: TryNewWheelTimerFunc() err = %v, expected nil", tc.policy, err)
+		}
+
+		victim, kept := late, early
+		if tc.wantEarly {
+			victim, kept = early, late
+		}
+		if err := victim.TryRelease(); err != nil {
+			t.Fatalf("%v: evicted timer TryRelease() err = %v, expected nil", tc.policy, err)
+		}
+		if err := kept.TryRelease(); !errors.Is(err, ErrTimerActive) {
+			t.Fatalf("%v: kept timer TryRelease() err = %v, expected ErrTimerActive", tc.policy, err)
+		}
+		if s := w.Stats(); s.Evicted != 1 || s.Timers != 2 {
+			t.Fatalf("%v: Stats() = %+v, expected Evicted 1, Timers 2", tc.policy, s)
+		}
+	}
+}
+
+// TestGroupQuota 测试 Group 的配额。
+// 功能点：group 达到配额后只影响这个 group，其他 group 和时间轮上的 timer 不受影响；Stop 后配额释放。
+// 方法：配额为 1 的两个 group 分别创建 timer，检查第二次创建的返回值和 Group.Timers()。
+func TestGroupQuota(t *testing.T) {
+	w := newIdleTestWheel(t)
+	g1 := w.NewGroup(1, RejectNew)
+	g2 := w.NewGroup(1, RejectNew)
+
+	tm, err := g1.TryNewTimer(time.Hour)
+	if err != nil {
+		t.Fatalf("g1.TryNewTimer() err = %v, expected nil", err)
+	}
+	if _, err := g1.TryAfterFunc(time.Hour, func() {}); err != ErrTooManyTimers {
+		t.Fatalf("g1.TryAfterFunc() over quota err = %v, expected ErrTooManyTimers", err)
+	}
+	if _, err := g2.TryNewTicker(time.Hour); err != nil {
+		t.Fatalf("g2.TryNewTicker() err = %v, expected nil", err)
+	}
+	if w.NewTimer(time.Hour) == nil {
+		t.Fatal("wheel NewTimer() returned nil, expected timer outside of groups")
+	}
+	if n := g1.Timers(); n != 1 {
+		t.Fatalf("g1.Timers() = %d, expected 1", n)
+	}
+
+	tm.Stop()
+	if n := g1.Timers(); n != 0 {
+		t.Fatalf("g1.Timers() after Stop = %d, expected 0", n)
+	}
+	if _, err := g1.TryNewWheelTimerFunc(time.Hour, func(time.Time, ...interface{}) {}); err != nil {
+		t.Fatalf("g1.TryNewWheelTimerFunc() after Stop err = %v, expected nil", err)
+	}
+}
+
+// TestMaxTimersBlock 测试阻塞策略。
+// 功能点：BlockUntilFree 达到上限时阻塞，有 timer 到期后继续加入；时间轮 Stop 时返回 ErrWheelStopped。
+// 方法：上限为 1 的时间轮上在另一个 goroutine 加入第 2 个 timer，等它阻塞后手动推进时间轮让第 1 个 timer 到期，
+// 先从第 1 个 timer 的 C 读到值，再检查第 2 个 timer 加入成功；不依赖 select 多个分支同时就绪时的选择顺序。
+func TestMaxTimersBlock(t *testing.T) {
+	w := NewWheel(time.Hour, WithMaxTimers(1), WithLimitPolicy(BlockUntilFree))
+	blocked := func() bool {
+		w.Lock()
+		defer w.Unlock()
+		return w.blocked > 0
+	}
+	first := w.NewTimer(0)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.TryNewTimer(time.Hour)
+		done <- err
+	}()
+	requireEventually(t, time.Second, blocked, "TryNewTimer() did not block at the limit")
+	select {
+	case err := <-done:
+		t.Fatalf("TryNewTimer() returned %v before a slot was freed", err)
+	default:
+	}
+
+	w.onTick()
+	waitTime(t, first.C, time.Second, "first timer")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("TryNewTimer() err = %v, expected nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TryNewTimer() still blocked after timer fired")
+	}
+
+	go func() {
+		_, err := w.TryNewTimer(time.Hour)
+		done <- err
+	}()
+	requireEventually(t, time.Second, blocked, "TryNewTimer() did not block at the limit")
+	w.Stop()
+	select {
+	case err := <-done:
+		if err != ErrWheelStopped {
+			t.Fatalf("TryNewTimer() on stopped wheel err = %v, expected ErrWheelStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TryNewTimer() still blocked after wheel Stop")
+	}
+}
+
+// TestBlockUntilFreeWouldBlock 测试不能阻塞等待时的 BlockUntilFree。
+// 功能点：ManualWheel/SimWheel 中，以及在派发 callback 的 goroutine 中(普通和精确模式的 callback)达到上限时，
+// 时间轮和 group 的 BlockUntilFree 都不阻塞，返回 ErrWouldBlock 并计入 Stats().Rejected。
+// 方法：SimWheel 上直接超过上限；真实时间轮上让 callback 在时间轮或 group 中一直加入 timer 直到达到上限，检查返回的错误。
+func TestBlockUntilFreeWouldBlock(t *testing.T) {
+	noop := func(time.Time, ...interface{}) {}
+	sim := NewSimWheel(time.Millisecond, time.Unix(0, 0), WithMaxTimers(1), WithLimitPolicy(BlockUntilFree))
+	defer sim.m.Stop()
+	if _, err := sim.TryNewWheelTimerFunc(time.Hour, noop); err != nil {
+		t.Fatalf("SimWheel TryNewWheelTimerFunc() err = %v, expected nil", err)
+	}
+	if _, err := sim.TryNewWheelTimerFunc(time.Hour, noop); err != ErrWouldBlock {
+		t.Fatalf("SimWheel TryNewWheelTimerFunc() over limit err = %v, expected ErrWouldBlock", err)
+	}
+	if s := sim.Stats(); s.Rejected != 1 {
+		t.Fatalf("SimWheel Stats().Rejected = %d, expected 1", s.Rejected)
+	}
+
+	for _, precise := range []bool{false, true} {
+		for _, group := range []bool{false, true} {
+			opts := []Option{}
+			if !group {
+				opts = append(opts, WithMaxTimers(2), WithLimitPolicy(BlockUntilFree))
+			}
+			w := newTestWheel(t, time.Hour, opts...)
+			add := w.TryNewWheelTimerFunc
+			if group {
+				add = w.NewGroup(1, BlockUntilFree).TryNewWheelTimerFunc
+			}
+			if _, err := add(time.Hour, noop); err != nil {
+				t.Fatalf("precise=%v group=%v: TryNewWheelTimerFunc() err = %v, expected nil", precise, group, err)
+			}
+
+			done := make(chan error, 1)
+			//callback 执行时它自己已经离开时间轮, 一直加到达到上限为止
+			cb := func(time.Time, ...interface{}) {
+				var err error
+				for i := 0; i < 3 && err == nil; i++ {
+					_, err = add(time.Hour, noop)
+				}
+				done <- err
+			}
+			if precise {
+				w.NewPreciseTimerFunc(time.Millisecond, cb)
+			} else {
+				w.NewWheelTimerFunc(0, cb)
+				w.onTick()
+			}
+			select {
+			case err := <-done:
+				if err != ErrWouldBlock {
+					t.Fatalf("precise=%v group=%v: add in callback err = %v, expected ErrWouldBlock", precise, group, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("precise=%v group=%v: add in callback blocked", precise, group)
+			}
+		}
+	}
+}
+
+// TestVictimMatchesScan 测试淘汰时查找最早和最晚到期的 timer。
+// 功能点：不遍历时间轮，只看每一层第一个/最后一个非空的槽、overflow 和 precise 队列的缓存，找到的 timer 的 expires
+// 和遍历所有链表得到的最早/最晚的一样；group 的淘汰只在 group 自己的 timer 中找。
+// 方法：小层级的 ManualWheel 中随机加入、Stop 普通和精确模式的 timer 并推进时间，每一步对比 victim 和遍历的结果。
+func TestVictimMatchesScan(t *testing.T) {
+	m := NewManualWheel(time.Millisecond, time.Unix(0, 0), WithGeometry(4, 3, 3), WithCascadeBatch(2))
+	defer m.Stop()
+	w := m.w
+	g := w.NewGroup(0, RejectNew)
+	rnd := rand.New(rand.NewSource(1))
+	var live []*WheelTimer
+	f := func(time.Time, ...interface{}) {}
+	scan := func(group *Group, latest bool) (uint64, bool) {
+		var v uint64
+		found := false
+		visit := func(l *timerList) {
+			for e := l.Front(); e != nil; e = e.Next() {
+				if group != nil && e.group != group {
+					continue
+				}
+				if !found || (latest && e.expires > v) || (!latest && e.expires < v) {
+					v, found = e.expires, true
+				}
+			}
+		}
+		for _, tv := range w.tv {
+			for i := range tv {
+				visit(&tv[i])
+			}
+		}
+		visit(&w.overflow)
+		visit(&w.precise)
+		return v, found
+	}
+	for step := 0; step < 3000; step++ {
+		switch r := rnd.Intn(10); {
+		case r < 5:
+			d := time.Duration(rnd.Int63n(1500)) * time.Millisecond
+			var tm *WheelTimer
+			switch rnd.Intn(3) {
+			case 0:
+				tm = m.NewWheelTimerFunc(d, f)
+			case 1:
+				tm = m.NewPreciseTimerFunc(d, f)
+			default:
+				tm, _ = g.TryNewWheelTimerFunc(d, f)
+			}
+			live = append(live, tm)
+		case r < 7 && len(live) > 0:
+			i := rnd.Intn(len(live))
+			live[i].Stop()
+			live = append(live[:i], live[i+1:]...)
+		default:
+			m.Advance(m.Now().Add(time.Duration(rnd.Int63n(40)) * time.Millisecond))
+		}
+		for _, group := range []*Group{nil, g} {
+			for _, latest := range []bool{false, true} {
+				want, ok := scan(group, latest)
+				v := w.victim(group, latest)
+				if (v != nil) != ok || (ok && v.expires != want) {
+					t.Fatalf("step %d group=%v latest=%v: victim = %v, expected expires %d, %v", step, group != nil, latest, v, want, ok)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+//go:build timerlockhold
+// +build timerlockhold
+
+package timer
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+/*
+# 需要 timerlockhold tag, 这个 tag 下 wheelMutex 才会记录持有锁的时间
+go test -tags timerlockhold -run '^$' -bench CascadeLockHold -benchtime=20x
+*/
+
+// BenchmarkCascadeLockHold 对比一次 cascade 整个槽和分批、提前迁移时, tick goroutine 每次持有 w.Lock 的时间。
+// 大量 timer 落在 tv2 的同一个槽里, 逐 tick 推进到这个槽 cascade 完, 报告持有锁时间的分位数。
+// 持有锁的时间就是并发的 add/Stop 最多需要等待的时间。
+func BenchmarkCascadeLockHold(b *testing.B) {
+	const timers = 1 << 16
+	for _, bc := range []struct {
+		name  string
+		batch int
+	}{
+		{name: "whole-slot", batch: 0},
+		{name: "batched", batch: defaultCascadeBatch},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var holds []time.Duration
+			f := func(time.Time, ...interface{}) {}
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				w := newWheel(benchTick, WithLogger(benchDiscardLogger{}), WithCascadeBatch(bc.batch))
+				for j := 0; j < timers; j++ {
+					t := w.newTimer(0, 0, f)
+					t.expires = 1280 + uint64(j%256) //tv2 的第 5 个槽
+					w.addTimer(t)
+				}
+				w.onHold = func(d time.Duration) { holds = append(holds, d) }
+				b.StartTimer()
+				for w.jiffies < 1536 {
+					w.expire()
+				}
+				b.StopTimer()
+				w.onHold = nil
+				w.cancelCtx()
+			}
+			sort.Slice(holds, func(i, j int) bool { return holds[i] < holds[j] })
+			at := func(p float64) float64 {
+				return float64(holds[int(p*float64(len(holds)-1))].Nanoseconds())
+			}
+			b.ReportMetric(at(0.5), "p50-hold-ns")
+			b.ReportMetric(at(0.99), "p99-hold-ns")
+			b.ReportMetric(at(0.999), "p999-hold-ns")
+			b.ReportMetric(at(1), "max-hold-ns")
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package timer
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ManualWheel 是由调用者驱动的时间轮: 和 Wheel 一样的 tv1..tv5 结构, 但没有内部的 tick goroutine,
+// 到期的 callback 在 Advance 中直接执行。适合 epoll 之类的事件循环, 用 NextDeadline 计算 poll 的超时。
+// 只能在一个 goroutine 中使用(包括 callback 里对 timer 的操作), 不加锁
+type ManualWheel struct {
+	w      *Wheel
+	start  time.Time //jiffies 为 0 的时间
+	cur    time.Time //当前时间: 执行 callback 时是这个槽到期的时间, Advance 返回后是 Advance 传入的时间
+	sorted bool      //同一个槽里的 timer 按 deadline 排序后执行, 见 SimWheel
+}
+
+// NewManualWheel 创建从 now 开始计时的 ManualWheel
+func NewManualWheel(tick time.Duration, now time.Time, opts ...Option) *ManualWheel {
+	m := &ManualWheel{start: now, cur: now}
+	opts = append(opts[:len(opts):len(opts)], func(w *Wheel) {
+		w.single = true
+		w.now = m.Now
+	})
+	m.w = newWheel(tick, opts...)
+	return m
+}
+
+// Now 返回时间轮的当前时间, callback 中是这个 timer 所在的槽到期的时间
+func (m *ManualWheel) Now() time.Time {
+	return m.cur
+}
+
+// Advance 把时间轮推进到 now, 在当前 goroutine 中按到期时间依次执行到期的 callback。
+// 没有到期 timer 也不需要 cascade 的 tick 直接跳过, 推进很长的时间也不需要逐个 tick 处理。
+// precise 队列里的 timer 不等 runtime timer, 在 deadline 和槽的到期时间之间按时间顺序执行
+func (m *ManualWheel) Advance(now time.Time) {
+	if now.Before(m.cur) {
+		return
+	}
+	w := m.w
+	target := uint64(now.Sub(m.start) / w.tick)
+	for {
+		next, has := w.nextEvent()
+		ok := has && next < target
+		if d, pok := m.preciseDeadline(); pok && !d.After(now) && (!ok || !d.After(m.slotTime(next))) {
+			//jiffies 跟上 d, callback 中新加的 timer 按 d 计算到期的槽; 到 next 之前的槽都是空的, 可以直接跳过
+			if j := uint64(d.Sub(m.start) / w.tick); j > w.jiffies && (!has || j <= next) {
+				atomic.StoreUint64(&w.jiffies, j)
+			}
+			m.cur = d
+			w.runPrecise()
+			continue
+		}
+		if !ok {
+			break
+		}
+		atomic.StoreUint64(&w.jiffies, next)
+		m.cur = m.slotTime(next)
+		list := w.expire()
+		if list.Empty() {
+			continue
+		}
+		if m.sorted {
+			sortByDeadline(&list)
+		}
+		atomic.AddInt32(&w.taskRuning, 1)
+		w.runList(list)
+	}
+	if w.jiffies < target {
+		atomic.StoreUint64(&w.jiffies, target)
+	}
+	m.cur = now
+}
+
+// slotTime 返回 jiffies 的槽到期的时间: onTick 处理 jiffies 为 j 的槽是在 start + (j+1)*tick
+func (m *ManualWheel) slotTime(j uint64) time.Time {
+	return m.start.Add(time.Duration(j+1) * m.w.tick)
+}
+
+// preciseDeadline 返回 precise 队列中最早的 deadline
+func (m *ManualWheel) preciseDeadline() (time.Time, bool) {
+	t := m.w.precise.Front()
+	if t == nil {
+		return time.Time{}, false
+	}
+	return m.start.Add(time.Duration(t.deadline - m.start.UnixNano())), true
+}
+
+// NextDeadline 返回最早到期的 timer 需要 Advance 到的时间, 没有 timer 时 ok 为 false
+func (m *ManualWheel) NextDeadline() (deadline time.Time, ok bool) {
+	expires, ok := m.w.nextExpiry()
+	if ok {
+		deadline = m.slotTime(expires)
+	}
+	if d, pok := m.preciseDeadline(); pok && (!ok || d.Before(deadline)) {
+		deadline, ok = d, true
+	}
+	return deadline, ok
+}
+
+func (m *ManualWheel) Timers() int {
+	return m.w.timers
+}
+
+func (m *ManualWheel) NewWheelTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	return m.w.NewWheelTimerFunc(d, f, arg...)
+}
+
+func (m *ManualWheel) NewWheelTimerInfoFunc(d time.Duration, f func(FireInfo, ...interface{}), arg ...interface{}) *WheelTimer {
+	return m.w.NewWheelTimerInfoFunc(d, f, arg...)
+}
+
+// NewPreciseTimerFunc 见 Wheel.NewPreciseTimerFunc, callback 在 Advance 中按 deadline 执行
+func (m *ManualWheel) NewPreciseTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	return m.w.NewPreciseTimerFunc(d, f, arg...)
+}
+
+func (m *ManualWheel) NewDynamicTimer(first time.Duration, f func(FireInfo) (next time.Duration, stop bool)) *WheelTimer {
+	return m.w.NewDynamicTimer(first, f)
+}
+
+// Stop 之后新的 timer 不能再加入, 执行中的 ctx callback 被 cancel
+func (m *ManualWheel) Stop() {
+	w := m.w
+	close(w.quit)
+	w.close = true
+	w.cancelCtx()
+}
+
+// nextExpiry 返回最早到期的 timer 的 expires(已经过期的按当前 jiffies 算), 调用者持有 w.Lock。
+// tv1 中第一个非空的槽就是 tv1 里最早的; 更高层的槽按时间排序, 第一个非空的槽里最早的就是这一层最早的, 见 firstSlot。
+// 找非空的槽只需要查每一层的 bitmap, 高层的槽和 overflow 里最早的 timer 从 bounds 中取, 均摊 O(层数), 见 bounds
+func (w *Wheel) nextExpiry() (uint64, bool) {
+	w.drainLocked()
+	var best uint64
+	found := false
+	consider := func(expires uint64) {
+		if !found || expires < best {
+			best, found = expires, true
+		}
+	}
+
+	if j, ok := w.firstRoot(); ok {
+		consider(j)
+	}
+	for level := 1; level < len(w.tv); level++ {
+		if l, _ := w.firstSlot(level); l != nil {
+			min, _ := w.slotBounds(level, l).get(l)
+			consider(min.expires)
+		}
+	}
+	if min, _ := w.overflowBounds.get(&w.overflow); min != nil {
+		consider(min.expires)
+	}
+	return best, found
+}
+
+// slotBounds 返回第 level 层(level >= 1)的槽 l 的 bounds
+func (w *Wheel) slotBounds(level int, l *timerList) *bounds {
+	return &w.bounds[level][l.Front().slot]
+}
+
+// firstRoot 返回 tv1 中从当前 jiffies 开始第一个非空的槽的 jiffies
+func (w *Wheel) firstRoot() (uint64, bool) {
+	cur := w.jiffies & w.rootMask
+	i, ok := w.occ[0].next(cur, w.rootMask+1)
+	if !ok {
+		return 0, false
+	}
+	return w.jiffies + (i-cur)&w.rootMask, true
+}
+
+// firstSlot 返回第 level 层(level >= 1)按时间顺序第一个非空的槽, 以及这个槽 cascade 的 jiffies。
+// 当前槽在 jiffies 正好是这一层的边界时还没有 cascade, 排在最前面; 否则当前槽已经 cascade 过,
+// 里面只有转了一圈之后才到期的 timer, 排在最后
+func (w *Wheel) firstSlot(level int) (*timerList, uint64) {
+	shift := w.levelShift(level)
+	c := w.jiffies >> shift
+	k := uint64(1)
+	if w.jiffies&(1<<shift-1) == 0 {
+		k = 0
+	}
+	from := (c + k) & w.levelMask
+	i, ok := w.occ[level].next(from, w.levelMask+1)
+	if !ok {
+		return nil, 0
+	}
+	k += (i - from) & w.levelMask
+	return &w.tv[level][i], (c + k) << shift
+}
+
+// nextEvent 返回下一个需要执行 expire 的 jiffies: tv1 中第一个非空的槽, 某一层第一个非空的槽 cascade 的时间,
+// 或者有 overflow 时最高层的下一次 cascade。之间的 tick 既没有到期的 timer 也不需要 cascade, 可以直接跳过。
+// 每一层只查 bitmap, O(层数)。调用者持有 w.Lock
+func (w *Wheel) nextEvent() (uint64, bool) {
+	w.drainLocked()
+	var best uint64
+	found := false
+	consider := func(j uint64) {
+		if !found || j < best {
+			best, found = j, true
+		}
+	}
+
+	if j, ok := w.firstRoot(); ok {
+		consider(j)
+	}
+	for level := 1; level < len(w.tv); level++ {
+		if l, j := w.firstSlot(level); l != nil {
+			consider(j)
+		}
+	}
+	if w.overflowTimers > 0 {
+		shift := w.levelShift(len(w.tv) - 1)
+		consider((w.jiffies + 1<<shift - 1) >> shift << shift)
+	}
+	return best, found
+}
+
+// sortByDeadline 把同一个槽里的 timer 按 deadline 稳定排序
+func sortByDeadline(list *timerList) {
+	ts := make([]*timer, 0, list.Len())
+	for !list.Empty() {
+		t := list.Front()
+		list.Remove(t)
+		ts = append(ts, t)
+	}
+	sort.SliceStable(ts, func(i, j int) bool { return ts[i].deadline < ts[j].deadline })
+	for _, t := range ts {
+		list.PushBack(t)
+	}
+}
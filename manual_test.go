@@ -0,0 +1,101 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestManualWheelAdvance 测试由调用者驱动的时间轮。
+// 功能点：Advance 返回前到期的 callback 已经在当前 goroutine 执行，callback 收到的时间是 Advance 传入的时间；
+// 没到期的 timer 不执行；NextDeadline 返回最早到期的 timer 的时间，没有 timer 时返回 false。
+// 方法：从固定时间开始创建两个 timer，分别 Advance 到 deadline 之前和之后，检查执行顺序和 NextDeadline。
+func TestManualWheelAdvance(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tick := 10 * time.Millisecond
+	m := NewManualWheel(tick, start)
+	defer m.Stop()
+
+	if _, ok := m.NextDeadline(); ok {
+		t.Fatal("NextDeadline() of empty wheel ok = true, expected false")
+	}
+
+	var fired []int
+	var firedAt []time.Time
+	f := func(now time.Time, arg ...interface{}) {
+		fired = append(fired, arg[0].(int))
+		firedAt = append(firedAt, now)
+	}
+	m.NewWheelTimerFunc(50*time.Millisecond, f, 1)
+	m.NewWheelTimerFunc(20*time.Millisecond, f, 2)
+
+	next, ok := m.NextDeadline()
+	if !ok {
+		t.Fatal("NextDeadline() ok = false, expected true")
+	}
+	m.Advance(next.Add(-time.Nanosecond))
+	if len(fired) != 0 {
+		t.Fatalf("fired %v before NextDeadline %v", fired, next)
+	}
+	m.Advance(next)
+	if len(fired) != 1 || fired[0] != 2 || !firedAt[0].Equal(next) {
+		t.Fatalf("fired = %v at %v, expected [2] at %v", fired, firedAt, next)
+	}
+
+	next, _ = m.NextDeadline()
+	if want := start.Add(50 * time.Millisecond); next.Before(want) {
+		t.Fatalf("NextDeadline() = %v, expected not before %v", next, want)
+	}
+	m.Advance(start.Add(time.Second))
+	if len(fired) != 2 || fired[1] != 1 {
+		t.Fatalf("fired = %v, expected [2 1]", fired)
+	}
+	if n := m.Timers(); n != 0 {
+		t.Fatalf("Timers() = %d, expected 0", n)
+	}
+}
+
+// TestManualWheelNextDeadlineAcrossLevels 测试 NextDeadline 对高层 timer 的计算。
+// 功能点：timer 在 tv2 及以上时 NextDeadline 仍然准确，Advance 到这个时间正好触发；周期 timer 可以用 Stop 停止。
+// 方法：创建到期在不同层的 timer 和一个周期 timer，反复 Advance 到 NextDeadline，检查每次都有 timer 执行。
+func TestManualWheelNextDeadlineAcrossLevels(t *testing.T) {
+	start := time.Unix(0, 0)
+	tick := time.Millisecond
+	m := NewManualWheel(tick, start, WithGeometry(4, 3, 4))
+	defer m.Stop()
+
+	fires, periodicFires := 0, 0
+	f := func(time.Time, ...interface{}) { fires++ }
+	for _, d := range []time.Duration{3, 40, 300, 2000, 70000} {
+		m.NewWheelTimerFunc(d*tick, f)
+	}
+	periodic := m.NewWheelTimerFunc(7*tick, func(time.Time, ...interface{}) {
+		fires++
+		periodicFires++
+	})
+	periodic.ResetTimer(7*tick, 7*tick)
+
+	for i := 0; i < 50; i++ {
+		next, ok := m.NextDeadline()
+		if !ok {
+			t.Fatal("NextDeadline() ok = false, expected true")
+		}
+		before := fires
+		m.Advance(next)
+		if fires == before {
+			t.Fatalf("Advance(NextDeadline()=%v) fired nothing", next.Sub(start))
+		}
+	}
+	if !periodic.Stop() {
+		t.Fatal("periodic Stop() = false, expected true")
+	}
+	for {
+		next, ok := m.NextDeadline()
+		if !ok {
+			break
+		}
+		m.Advance(next)
+	}
+	if fires != periodicFires+5 {
+		t.Fatalf("fires = %d, expected %d", fires, periodicFires+5)
+	}
+}
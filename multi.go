@@ -0,0 +1,115 @@
+package timer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MultiWheel 由多个不同 tick 的时间轮组成: 所有 timer 都属于 tick 最细的时间轮(嵌入的 *Wheel),
+// 但离到期还远的 timer 不放进它的 tv1..tv5, 而是等在能满足相对精度的最粗的时间轮中,
+// 随着到期时间临近逐级移到更细的时间轮, 最后由最细的时间轮触发。
+// 这样 1 小时的 timer 不需要在 1ms 的时间轮中反复 cascade, 5ms 的 timer 也能按 1ms 的精度触发。
+// Stop/Reset/Release/Handle 和 Wheel 的 timer 一样使用
+type MultiWheel struct {
+	*Wheel
+	wheels []*Wheel        //按 tick 从细到粗, wheels[0] 就是嵌入的 Wheel
+	hopAt  []time.Duration //timer 剩余的时间不超过 hopAt[k] 时离开 wheels[k]
+}
+
+// NewMultiWheel 用 ticks 创建多个时间轮, accuracy 是 timer 等待期间允许的相对误差(0, 1]:
+// 剩余时间为 r 的 timer 等在 tick 不超过 accuracy*r 的最粗的时间轮中。opts 作用于最细的时间轮
+func NewMultiWheel(ticks []time.Duration, accuracy float64, opts ...Option) *MultiWheel {
+	if len(ticks) == 0 {
+		panic("ticks must not be empty")
+	}
+	if accuracy <= 0 || accuracy > 1 {
+		panic("accuracy must be in (0, 1]")
+	}
+	ticks = append([]time.Duration(nil), ticks...)
+	sort.Slice(ticks, func(i, j int) bool { return ticks[i] < ticks[j] })
+
+	m := &MultiWheel{}
+	opts = append(opts[:len(opts):len(opts)], func(w *Wheel) {
+		w.multi = m
+	})
+	m.Wheel = NewWheel(ticks[0], opts...)
+	m.wheels = []*Wheel{m.Wheel}
+	m.hopAt = []time.Duration{0}
+	for _, tick := range ticks[1:] {
+		w := NewWheel(tick, WithName(fmt.Sprintf("%s/%v", m.name, tick)), WithLogger(m.log), WithAutoRelease())
+		m.wheels = append(m.wheels, w)
+		//hop timer 最多晚 2 个 tick 触发, 提前 2 个 tick 离开, 保证不会晚于 deadline
+		m.hopAt = append(m.hopAt, time.Duration(float64(tick)/accuracy)+2*tick)
+	}
+	return m
+}
+
+// route 返回剩余时间为 remaining 的 timer 应该等在哪个时间轮, 最粗不超过 wheels[max]
+func (m *MultiWheel) route(remaining time.Duration, max int) int {
+	k := max
+	for k > 0 && remaining <= m.hopAt[k] {
+		k--
+	}
+	return k
+}
+
+// park 在 wheels[0].addLocked 中调用, 调用者持有 wheels[0] 的锁。离到期还远的 t 放到 parked 列表,
+// 在 wheels[k] 中加一个 hop timer, 到时把 t 移到更细的时间轮; 返回 false 表示 t 直接放进 wheels[0]。
+// 锁的顺序总是 wheels[0] 在前, hop timer 的 callback 执行时不持有 wheels[k] 的锁, 不会死锁
+func (m *MultiWheel) park(t *timer, now int64, max int) bool {
+	remaining := time.Duration(t.deadline - now)
+	k := m.route(remaining, max)
+	if k == 0 {
+		return false
+	}
+	w := m.Wheel
+	w.parked.PushBack(t)
+	w.parkedTimers++
+	w.parkedBounds.add(t, w.parkedTimers == 1)
+	t.list = &w.parked
+	t.state = NotReady
+	t.hopSeq++
+	//hop 所在的时间轮是自动回收的, handle 需要在加入时间轮之前取得
+	t.hop = m.wheels[k].NewWheelTimerHandle(remaining-m.hopAt[k], m.hop, t, t.hopSeq, k)
+	return true
+}
+
+// hop 是 wheels[k] 中 hop timer 的 callback: t 还在等这个 hop 时, 把 t 移到更细的时间轮
+func (m *MultiWheel) hop(_ time.Time, arg ...interface{}) {
+	t, seq, k := arg[0].(*timer), arg[1].(uint32), arg[2].(int)
+	w := m.Wheel
+	w.Lock()
+	defer w.Unlock()
+	if t.list != &w.parked || t.hopSeq != seq {
+		//已经被 Stop 或者 Reset
+		return
+	}
+	w.unlink(t)
+	t.list = nil
+	t.hop = Handle{}
+	now := w.now().UnixNano()
+	if !m.park(t, now, k-1) {
+		w.place(t, now)
+	}
+}
+
+// cancelHop 在 t 离开 parked 列表时停止它的 hop timer, 调用者持有 wheels[0] 的锁
+func (m *MultiWheel) cancelHop(t *timer) {
+	h := t.hop
+	t.hop = Handle{}
+	//hop timer 已经到期时 Stop 失败, 它的 callback 会发现 t.hopSeq 已经变了
+	h.Stop()
+}
+
+// Wheels 返回按 tick 从细到粗的所有时间轮, 用来查看每个时间轮中等待的 timer 数量
+func (m *MultiWheel) Wheels() []*Wheel {
+	return append([]*Wheel(nil), m.wheels...)
+}
+
+// Stop 停止所有时间轮
+func (m *MultiWheel) Stop() {
+	for i := len(m.wheels) - 1; i >= 0; i-- {
+		m.wheels[i].Stop()
+	}
+}
@@ -0,0 +1,100 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestMultiWheel(t *testing.T, ticks []time.Duration, accuracy float64, opts ...Option) *MultiWheel {
+	t.Helper()
+
+	m := NewMultiWheel(ticks, accuracy, opts...)
+	t.Cleanup(m.Stop)
+	return m
+}
+
+// TestMultiWheelRouting 测试 MultiWheel 按剩余时间选择时间轮。
+// 功能点：离到期还远的 timer 等在能满足精度的最粗的时间轮中，短 timer 直接放进最细的时间轮；
+// Stop 之后更粗的时间轮中的 hop timer 也被移除；反复 Reset 不会留下多余的 hop timer。
+// 方法：1ms/10ms/100ms/1s 四个时间轮、accuracy 0.1，创建 1h、5s 和 5ms 的 timer，检查每个时间轮中的 timer 数量。
+func TestMultiWheelRouting(t *testing.T) {
+	m := newTestMultiWheel(t, []time.Duration{time.Second, time.Millisecond, 100 * time.Millisecond, 10 * time.Millisecond}, 0.1)
+	wheels := m.Wheels()
+	counts := func() []int {
+		var n []int
+		for _, w := range wheels {
+			n = append(n, w.Timers())
+		}
+		return n
+	}
+	expect := func(want ...int) {
+		t.Helper()
+		got := counts()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("timers per wheel = %v, expected %v", got, want)
+			}
+		}
+	}
+
+	f := func(time.Time, ...interface{}) {}
+	hour := m.NewWheelTimerFunc(time.Hour, f)
+	expect(1, 0, 0, 1)
+	sec := m.NewWheelTimerFunc(5*time.Second, f)
+	expect(2, 0, 1, 1)
+	short := m.NewWheelTimerFunc(5*time.Millisecond, f)
+	expect(3, 0, 1, 1)
+	short.Stop()
+	expect(2, 0, 1, 1)
+
+	for i := 0; i < 100; i++ {
+		hour.ResetTimer(time.Hour, 0)
+	}
+	expect(2, 0, 1, 1)
+	if !hour.Stop() || !sec.Stop() {
+		t.Fatal("Stop() of parked timer = false, expected true")
+	}
+	expect(0, 0, 0, 0)
+	if n := m.RealTimers(); n != 0 {
+		t.Fatalf("RealTimers() = %d, expected 0", n)
+	}
+}
+
+// TestMultiWheelFireAccuracy 测试 MultiWheel 中 timer 的精度。
+// 功能点：timer 从粗的时间轮逐级移到最细的时间轮，最后按最细的 tick 触发，不会早于也不会明显晚于 deadline；
+// 周期 timer 每次重新加入时也按同样的方式路由。
+// 方法：1ms/20ms/100ms 三个时间轮、accuracy 0.5，创建 600ms 的 timer 和 5ms 的 timer 以及 300ms 的 ticker，检查触发时间。
+func TestMultiWheelFireAccuracy(t *testing.T) {
+	m := newTestMultiWheel(t, []time.Duration{time.Millisecond, 20 * time.Millisecond, 100 * time.Millisecond}, 0.5)
+	wheels := m.Wheels()
+
+	start := time.Now()
+	long := m.NewTimer(600 * time.Millisecond)
+	short := m.NewTimer(5 * time.Millisecond)
+	ticker := m.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+	if n1, n2 := wheels[1].Timers(), wheels[2].Timers(); n1 != 1 || n2 != 1 {
+		t.Fatalf("20ms/100ms wheel has %d/%d hop timers, expected 1/1", n1, n2)
+	}
+
+	check := func(name string, fired time.Time, want time.Duration) {
+		t.Helper()
+		if d := fired.Sub(start); d < want || d > want+40*time.Millisecond {
+			t.Fatalf("%s fired after %v, expected about %v", name, d, want)
+		}
+	}
+	check("5ms timer", waitTime(t, short.C, time.Second, "5ms timer"), 5*time.Millisecond)
+	check("ticker", waitTime(t, ticker.C, time.Second, "ticker"), 300*time.Millisecond)
+	check("600ms timer", waitTime(t, long.C, time.Second, "600ms timer"), 600*time.Millisecond)
+	check("ticker", waitTime(t, ticker.C, time.Second, "ticker"), 600*time.Millisecond)
+}
+
+// TestMultiWheelSetTick 测试 MultiWheel 的 tick 不能修改。
+// 功能点：SetTick 返回 ErrTickFixed。
+// 方法：调用 SetTick 检查返回值。
+func TestMultiWheelSetTick(t *testing.T) {
+	m := newTestMultiWheel(t, []time.Duration{time.Millisecond, time.Second}, 0.1)
+	if err := m.SetTick(time.Second); err != ErrTickFixed {
+		t.Fatalf("SetTick() err = %v, expected %v", err, ErrTickFixed)
+	}
+}
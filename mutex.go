@@ -0,0 +1,25 @@
+//go:build !timerlockhold
+// +build !timerlockhold
+
+package timer
+
+import "sync"
+
+// wheelMutex 在 ManualWheel 中只有一个 goroutine 使用, 不需要加锁。
+// 测量持有锁时间的版本在 mutex_lockhold.go 中, 只在 timerlockhold tag 下编译
+type wheelMutex struct {
+	sync.Mutex
+	single bool
+}
+
+func (m *wheelMutex) Lock() {
+	if !m.single {
+		m.Mutex.Lock()
+	}
+}
+
+func (m *wheelMutex) Unlock() {
+	if !m.single {
+		m.Mutex.Unlock()
+	}
+}
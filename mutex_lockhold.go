@@ -0,0 +1,37 @@
+//go:build timerlockhold
+// +build timerlockhold
+
+package timer
+
+import (
+	"sync"
+	"time"
+)
+
+// wheelMutex 是 BenchmarkCascadeLockHold 用的版本: onHold 不为 nil 时 Unlock 报告这次持有锁的时间。
+// 只在 timerlockhold tag 下编译, 正常编译的版本见 mutex.go
+type wheelMutex struct {
+	sync.Mutex
+	single bool
+
+	onHold func(time.Duration)
+	locked time.Time
+}
+
+func (m *wheelMutex) Lock() {
+	if !m.single {
+		m.Mutex.Lock()
+	}
+	if m.onHold != nil {
+		m.locked = time.Now()
+	}
+}
+
+func (m *wheelMutex) Unlock() {
+	if m.onHold != nil {
+		m.onHold(time.Since(m.locked))
+	}
+	if !m.single {
+		m.Mutex.Unlock()
+	}
+}
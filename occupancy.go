@@ -0,0 +1,235 @@
+package timer
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// defaultCascadeBatch 默认每次持有锁最多 cascade 的 timer 数量, 见 WithCascadeBatch
+const defaultCascadeBatch = 256
+
+// WithCascadeBatch 设置每次持有 w.Lock 最多 cascade 多少个 timer: 一个很大的高层槽 cascade 时分批进行,
+// 批之间释放锁, add/Stop 不会被整个槽的迁移卡住; 另外 tv2 下一个要 cascade 的槽在之前的 tick 里
+// 每个 tick 提前迁移最多 n 个已经可以放进 tv1 的 timer。n 为 0 表示一次持有锁 cascade 整个槽, 也不提前迁移
+func WithCascadeBatch(n int) Option {
+	if n < 0 {
+		panic("cascade batch must not be negative")
+	}
+	return func(w *Wheel) {
+		w.cascadeBatch = n
+	}
+}
+
+// bitmap 记录每一层哪些槽非空
+type bitmap []uint64
+
+func newBitmap(n int) bitmap {
+	return make(bitmap, (n+63)/64)
+}
+
+func (b bitmap) set(i uint64) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+func (b bitmap) clear(i uint64) {
+	b[i/64] &^= 1 << (i % 64)
+}
+
+// next 返回从 i 开始(到 n 之后回到 0)第一个非空的槽, 没有时 ok 为 false
+func (b bitmap) next(i, n uint64) (uint64, bool) {
+	w := i / 64
+	if word := b[w] &^ (1<<(i%64) - 1); word != 0 {
+		return w*64 + uint64(bits.TrailingZeros64(word)), true
+	}
+	for k := 1; k <= len(b); k++ {
+		j := (int(w) + k) % len(b)
+		word := b[j]
+		if j == int(w) {
+			//转了一圈回到 i 所在的 word, 只看 i 之前的部分
+			word &= 1<<(i%64) - 1
+		}
+		if word != 0 {
+			if idx := uint64(j)*64 + uint64(bits.TrailingZeros64(word)); idx < n {
+				return idx, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// prev 返回从 i 开始往前(到 0 之后回到 n-1)第一个非空的槽, 没有时 ok 为 false
+func (b bitmap) prev(i, n uint64) (uint64, bool) {
+	w := i / 64
+	upto := uint64(2)<<(i%64) - 1 //i 和 i 之前的 bit, i%64 为 63 时是全 1
+	if word := b[w] & upto; word != 0 {
+		return w*64 + uint64(63-bits.LeadingZeros64(word)), true
+	}
+	for k := 1; k <= len(b); k++ {
+		j := (int(w) - k + len(b)) % len(b)
+		word := b[j]
+		if j == int(w) {
+			//转了一圈回到 i 所在的 word, 只看 i 之后的部分
+			word &^= upto
+		}
+		if word != 0 {
+			if idx := uint64(j)*64 + uint64(63-bits.LeadingZeros64(word)); idx < n {
+				return idx, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// bounds 缓存一个链表中 expires 最早和最晚的 timer。加入 timer 时直接更新; 移除的正好是 min 或 max 时清空,
+// 下次 get 遍历一次链表重新计算。min 为 nil 并且链表不为空表示需要重新计算。
+// 高层的一个槽里 expires 不同, 查找最早/最晚的 timer 时用它代替遍历整个槽: 均摊下来只有 min/max 被移除之后的第一次查询需要遍历,
+// 最坏情况(反复移除最早的 timer 再查询)每次还是 O(槽的长度), 见 BenchmarkNextExpiry
+type bounds struct {
+	min, max *timer
+}
+
+// add 在 t 加入链表之后调用, first 表示加入之前链表为空
+func (b *bounds) add(t *timer, first bool) {
+	if first {
+		b.min, b.max = t, t
+		return
+	}
+	if b.min == nil {
+		return
+	}
+	if t.expires < b.min.expires {
+		b.min = t
+	}
+	if t.expires >= b.max.expires {
+		b.max = t
+	}
+}
+
+// remove 在 t 从链表中移除之后调用
+func (b *bounds) remove(t *timer) {
+	if t == b.min || t == b.max {
+		*b = bounds{}
+	}
+}
+
+// get 返回 l 中 expires 最早和最晚的 timer, l 为空时返回 nil
+func (b *bounds) get(l *timerList) (min, max *timer) {
+	if b.min == nil {
+		for t := l.Front(); t != nil; t = t.Next() {
+			if b.min == nil || t.expires < b.min.expires {
+				b.min = t
+			}
+			if b.max == nil || t.expires >= b.max.expires {
+				b.max = t
+			}
+		}
+	}
+	return b.min, b.max
+}
+
+// link 把 t 放到第 level 层的第 i 个槽, 调用者持有 w.Lock
+func (w *Wheel) link(t *timer, level int, i uint64) {
+	l := &w.tv[level][i]
+	l.PushBack(t)
+	t.list = l
+	t.level = int32(level)
+	t.slot = uint32(i)
+	w.occ[level].set(i)
+	w.levelTimers[level]++
+	if level > 0 {
+		w.bounds[level][i].add(t, l.Len() == 1)
+	}
+}
+
+// unlink 把 t 从所在的链表中移除并维护每一层的 timer 数量和非空的槽, 调用者持有 w.Lock
+func (w *Wheel) unlink(t *timer) {
+	l := t.list
+	l.Remove(t)
+	switch l {
+	case &w.overflow:
+		w.overflowTimers--
+		w.overflowBounds.remove(t)
+	case &w.precise:
+		w.preciseTimers--
+	case &w.parked:
+		w.parkedTimers--
+		w.parkedBounds.remove(t)
+	default:
+		w.levelTimers[t.level]--
+		if l.Empty() {
+			w.occ[t.level].clear(uint64(t.slot))
+		}
+		if t.level > 0 {
+			w.bounds[t.level][t.slot].remove(t)
+		}
+	}
+}
+
+// takeSlot 取出第 level 层第 i 个槽中的所有 timer, 只是把整个链表摘下来, O(1)。
+// 取出的 timer 的 t.list 还指向这个槽, 由调用者重新设置, 调用者持有 w.Lock
+func (w *Wheel) takeSlot(level int, i uint64) timerList {
+	var list timerList
+	list.PushBackList(&w.tv[level][i])
+	w.occ[level].clear(i)
+	w.levelTimers[level] -= list.Len()
+	if level > 0 {
+		w.bounds[level][i] = bounds{}
+	}
+	return list
+}
+
+// Occupancy 返回 tv1..tvN 每一层中的 timer 数量, 最后一个是超出最高层范围的 timer 数量, O(层数)
+func (w *Wheel) Occupancy() []int {
+	w.Lock()
+	defer w.Unlock()
+	w.drainLocked()
+	n := append([]int(nil), w.levelTimers...)
+	return append(n, w.overflowTimers)
+}
+
+// cascadeSlot 把第 level 层第 index 个槽中的 timer 重新放到低层, 每 w.cascadeBatch 个释放一次锁。
+// 释放锁期间新加入的 timer 不会落到这个槽里(这个槽对应的时间已经进入低层的范围), Stop 也可以正常进行
+func (w *Wheel) cascadeSlot(level int, index uint64) {
+	list := &w.tv[level][index]
+	var n uint64
+	for batch := 0; !list.Empty(); batch++ {
+		if batch == w.cascadeBatch && batch > 0 {
+			w.Unlock()
+			w.Lock()
+			batch = 0
+		}
+		t := list.Front()
+		w.unlink(t)
+		w.addTimerInternal(t)
+		n++
+	}
+	if n > 0 {
+		atomic.AddUint64(&w.cascaded, n)
+	}
+}
+
+// precascade 提前迁移 tv2 中下一个要 cascade 的槽: 检查最多 w.cascadeBatch 个 timer,
+// 已经在 tv1 一圈之内到期的放进 tv1, 其他的移到槽的末尾等之后的 tick 再检查。
+// 到 cascade 边界时这个槽里剩下的 timer 就少了, 迁移的开销分摊到之前的 tick 上
+func (w *Wheel) precascade() {
+	if w.cascadeBatch == 0 {
+		return
+	}
+	next := (w.jiffies | w.rootMask) + 1
+	index := (next >> w.rootBits) & w.levelMask
+	list := &w.tv[1][index]
+	for n := 0; n < w.cascadeBatch && !list.Empty(); n++ {
+		t := list.Front()
+		if t.expires-w.jiffies < 1<<w.rootBits {
+			w.unlink(t)
+			w.addTimerInternal(t)
+			atomic.AddUint64(&w.cascaded, 1)
+			continue
+		}
+		if list.Back() == t {
+			break
+		}
+		list.Remove(t)
+		list.PushBack(t)
+	}
+}
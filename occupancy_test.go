@@ -0,0 +1,242 @@
+package timer
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// checkOccupancy 遍历所有链表, 检查每一层的 timer 数量和 bitmap
+func checkOccupancy(t *testing.T, w *Wheel) {
+	t.Helper()
+
+	w.Lock()
+	defer w.Unlock()
+	count := func(l *timerList) int {
+		n := 0
+		for e := l.Front(); e != nil; e = e.Next() {
+			n++
+		}
+		if n != l.Len() {
+			t.Fatalf("list has %d timers, Len() = %d", n, l.Len())
+		}
+		return n
+	}
+	for level, tv := range w.tv {
+		n := 0
+		for i := range tv {
+			c := count(&tv[i])
+			n += c
+			if set := w.occ[level][i/64]&(1<<(uint(i)%64)) != 0; set != (c > 0) {
+				t.Fatalf("tv%d[%d] has %d timers but bit set = %v", level+1, i, c, set)
+			}
+		}
+		if n != w.levelTimers[level] {
+			t.Fatalf("tv%d has %d timers, levelTimers = %d", level+1, n, w.levelTimers[level])
+		}
+	}
+	if n := count(&w.overflow); n != w.overflowTimers {
+		t.Fatalf("overflow has %d timers, overflowTimers = %d", n, w.overflowTimers)
+	}
+	//缓存的 min/max 要么被清空, 要么和遍历链表的结果一致
+	checkBounds := func(name string, b bounds, l *timerList) {
+		if b.min == nil {
+			return
+		}
+		for e := l.Front(); e != nil; e = e.Next() {
+			if e.expires < b.min.expires || e.expires > b.max.expires {
+				t.Fatalf("%s has expires %d outside cached bounds [%d, %d]", name, e.expires, b.min.expires, b.max.expires)
+			}
+		}
+		if b.min.list != l || b.max.list != l {
+			t.Fatalf("%s cached bounds point to timers not in the list", name)
+		}
+	}
+	for level := 1; level < len(w.tv); level++ {
+		for i := range w.tv[level] {
+			checkBounds("slot", w.bounds[level][i], &w.tv[level][i])
+		}
+	}
+	checkBounds("overflow", w.overflowBounds, &w.overflow)
+
+	//nextExpiry 和遍历所有 timer 得到的最早 expires 一致, 已经过期的按当前 jiffies 算
+	var want uint64
+	found := false
+	for _, tv := range w.tv {
+		for i := range tv {
+			for e := tv[i].Front(); e != nil; e = e.Next() {
+				expires := e.expires
+				if int64(expires-w.jiffies) < 0 {
+					expires = w.jiffies
+				}
+				if !found || expires < want {
+					want, found = expires, true
+				}
+			}
+		}
+	}
+	for e := w.overflow.Front(); e != nil; e = e.Next() {
+		if !found || e.expires < want {
+			want, found = e.expires, true
+		}
+	}
+	if got, ok := w.nextExpiry(); ok != found || got != want {
+		t.Fatalf("nextExpiry() = %d, %v, expected %d, %v", got, ok, want, found)
+	}
+	if n := count(&w.precise); n != w.preciseTimers {
+		t.Fatalf("precise has %d timers, preciseTimers = %d", n, w.preciseTimers)
+	}
+}
+
+// TestBitmapNext 测试 bitmap 查找下一个非空的槽。
+// 功能点：从任意位置开始循环查找第一个置位的下标，跨 word 和回绕都正确，没有置位时返回 false。
+// 方法：随机置位后和逐个检查的结果对比。
+func TestBitmapNext(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []uint64{16, 64, 256, 1024} {
+		b := newBitmap(int(n))
+		set := make([]bool, n)
+		if _, ok := b.next(0, n); ok {
+			t.Fatalf("next() of empty bitmap ok = true, expected false")
+		}
+		for round := 0; round < 200; round++ {
+			i := uint64(rnd.Int63n(int64(n)))
+			if set[i] {
+				b.clear(i)
+			} else {
+				b.set(i)
+			}
+			set[i] = !set[i]
+			from := uint64(rnd.Int63n(int64(n)))
+			want, wantOK := uint64(0), false
+			for k := uint64(0); k < n; k++ {
+				if set[(from+k)%n] {
+					want, wantOK = (from+k)%n, true
+					break
+				}
+			}
+			if got, ok := b.next(from, n); ok != wantOK || got != want {
+				t.Fatalf("n=%d next(%d) = %d, %v, expected %d, %v", n, from, got, ok, want, wantOK)
+			}
+		}
+	}
+}
+
+// TestBitmapPrev 测试 bitmap 往前查找非空的槽。
+// 功能点：从任意位置开始往前循环查找最后一个置位的下标，跨 word 和回绕都正确，没有置位时返回 false。
+// 方法：随机置位后和逐个检查的结果对比。
+func TestBitmapPrev(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, n := range []uint64{16, 64, 256, 1024} {
+		b := newBitmap(int(n))
+		set := make([]bool, n)
+		if _, ok := b.prev(n-1, n); ok {
+			t.Fatalf("prev() of empty bitmap ok = true, expected false")
+		}
+		for round := 0; round < 200; round++ {
+			i := uint64(rnd.Int63n(int64(n)))
+			if set[i] {
+				b.clear(i)
+			} else {
+				b.set(i)
+			}
+			set[i] = !set[i]
+			from := uint64(rnd.Int63n(int64(n)))
+			want, wantOK := uint64(0), false
+			for k := uint64(0); k < n; k++ {
+				if j := (from + n - k) % n; set[j] {
+					want, wantOK = j, true
+					break
+				}
+			}
+			if got, ok := b.prev(from, n); ok != wantOK || got != want {
+				t.Fatalf("n=%d prev(%d) = %d, %v, expected %d, %v", n, from, got, ok, want, wantOK)
+			}
+		}
+	}
+}
+
+// TestOccupancyTracksLists 测试每一层的 timer 数量和 bitmap 的维护。
+// 功能点：add、Stop、cascade、提前迁移、到期、SetSlack 之后 timer 数量、bitmap 和每个槽缓存的最早/最晚 timer 都和链表一致，
+// nextExpiry 和遍历所有 timer 的结果相同；Occupancy 和 RealTimers 不遍历链表也能得到正确的数量。
+// 方法：小层级的 ManualWheel 中随机加入和 Stop timer 并推进时间，每一步之后遍历链表检查。
+func TestOccupancyTracksLists(t *testing.T) {
+	m := NewManualWheel(time.Millisecond, time.Unix(0, 0), WithGeometry(4, 3, 3), WithCascadeBatch(2))
+	defer m.Stop()
+	w := m.w
+	rnd := rand.New(rand.NewSource(1))
+	var live []*WheelTimer
+	f := func(time.Time, ...interface{}) {}
+	for step := 0; step < 3000; step++ {
+		switch r := rnd.Intn(10); {
+		case r < 5:
+			d := time.Duration(rnd.Int63n(1500)) * time.Millisecond
+			live = append(live, m.NewWheelTimerFunc(d, f))
+		case r < 7 && len(live) > 0:
+			i := rnd.Intn(len(live))
+			live[i].Stop()
+			live = append(live[:i], live[i+1:]...)
+		case r < 8 && len(live) > 0:
+			live[rnd.Intn(len(live))].SetSlack(time.Duration(rnd.Int63n(100)) * time.Millisecond)
+		default:
+			m.Advance(m.Now().Add(time.Duration(rnd.Int63n(40)) * time.Millisecond))
+		}
+		checkOccupancy(t, w)
+	}
+	levels := w.Occupancy()
+	total := 0
+	for _, n := range levels {
+		total += n
+	}
+	if n := w.RealTimers(); total != n || n != m.Timers() {
+		t.Fatalf("Occupancy() = %v, RealTimers() = %d, Timers() = %d, expected the same total", levels, n, m.Timers())
+	}
+}
+
+// TestBatchedCascadeFiresOnTime 测试分批 cascade 和提前迁移。
+// 功能点：每次持有锁只 cascade 几个 timer、并在之前的 tick 中提前迁移时，所有 timer 仍在 expires 那个 tick 执行，
+// 提前迁移确实减少了 cascade 边界上需要迁移的 timer。
+// 方法：逐 tick 驱动时间轮，大量 timer 落在同一个 tv2 槽里，检查每个 timer 执行时的 jiffies 和边界上 tv2 槽剩下的数量。
+func TestBatchedCascadeFiresOnTime(t *testing.T) {
+	w := newWheel(time.Millisecond, WithCascadeBatch(8), WithLogger(benchDiscardLogger{}))
+	w.single = true
+	defer w.cancelCtx()
+
+	const timers = 2000
+	var wrong int64
+	f := func(_ time.Time, arg ...interface{}) {
+		if want := arg[0].(uint64); atomic.LoadUint64(&w.jiffies)-1 != want {
+			atomic.AddInt64(&wrong, 1)
+		}
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < timers; i++ {
+		tm := w.newTimer(0, 0, f)
+		tm.expires = 1280 + uint64(rnd.Intn(256)) // 都在 tv2 的第 5 个槽
+		tm.arg = []interface{}{tm.expires}
+		w.addTimer(tm)
+	}
+
+	fired := 0
+	for w.jiffies < 1536 {
+		if w.jiffies == 1280 {
+			left := 0
+			for e := w.tv[1][5].Front(); e != nil; e = e.Next() {
+				left++
+			}
+			if left*2 > timers {
+				t.Fatalf("%d timers left in tv2 slot at the cascade boundary, expected most of them migrated early", left)
+			}
+		}
+		list := w.expire()
+		for e := list.Front(); e != nil; e = e.Next() {
+			fired++
+		}
+		atomic.AddInt32(&w.taskRuning, 1)
+		w.runList(list)
+	}
+	if fired != timers || wrong != 0 {
+		t.Fatalf("fired %d timers, %d at the wrong tick, expected %d on time", fired, wrong, timers)
+	}
+}
@@ -0,0 +1,113 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PeriodMode 表示周期 timer 执行完 callback 后如何重新调度
+type PeriodMode int
+
+const (
+	// FixedDelay 默认方式: callback 执行完后再等待一个周期, callback 执行慢会导致 ticker 漂移。
+	FixedDelay PeriodMode = iota
+	// FixedRate 以最初的调度时间为锚点, 每次在上一次的 expires 上加一个周期;
+	// 落后时会在接下来的 tick 中尽快补上错过的周期, Missed() 返回本次执行落后了多少个周期。
+	FixedRate
+	// SkipMissed 以最初的调度时间为锚点, 但跳过已经错过的周期, 直接调度到下一个未来的周期;
+	// Missed() 返回本次执行之前跳过了多少个周期。
+	SkipMissed
+)
+
+func (m PeriodMode) String() string {
+	switch m {
+	case FixedDelay:
+		return "FixedDelay"
+	case FixedRate:
+		return "FixedRate"
+	case SkipMissed:
+		return "SkipMissed"
+	}
+	return "Unknown"
+}
+
+// behind 返回 timer 在 now 时刻落后调度时间多少个周期
+func (w *Wheel) behind(t *timer, now time.Time) uint64 {
+	if t.precise {
+		late := now.UnixNano() - t.deadline
+		if late <= 0 || t.interval <= 0 {
+			return 0
+		}
+		return uint64(late / int64(t.interval))
+	}
+	//onTick 取出 timer 时已经 jiffies++, 当前 tick 是 jiffies-1
+	cur := atomic.LoadUint64(&w.jiffies) - 1
+	if cur <= t.expires {
+		return 0
+	}
+	return (cur - t.expires) / t.period
+}
+
+func (t *timer) periodMode() PeriodMode {
+	return PeriodMode(atomic.LoadInt32(&t.mode))
+}
+
+// setPeriodMode 修改周期 timer 的调度方式, 在持有 w.Lock 时检查 generation, t 已经不是 gen 这一代时返回 ErrReleased。
+// 下一次重新调度时生效
+func (w *Wheel) setPeriodMode(t *timer, gen uint32, mode PeriodMode) error {
+	w.Lock()
+	defer w.Unlock()
+	if !t.live(gen) {
+		return ErrReleased
+	}
+	atomic.StoreInt32(&t.mode, int32(mode))
+	return nil
+}
+
+// rearmPeriodic 在周期 timer 的 callback 执行完后, 按 t.mode 计算下一次的 deadline 和 expires
+func (w *Wheel) rearmPeriodic(t *timer) {
+	now := w.now().UnixNano()
+	if t.precise {
+		switch t.periodMode() {
+		case FixedRate:
+			t.deadline += int64(t.interval)
+		case SkipMissed:
+			t.deadline += int64(t.interval)
+			var missed uint64
+			if t.deadline < now && t.interval > 0 {
+				k := (now - t.deadline + int64(t.interval) - 1) / int64(t.interval)
+				t.deadline += k * int64(t.interval)
+				missed = uint64(k)
+			}
+			atomic.StoreUint64(&t.missed, missed)
+		default:
+			t.deadline = now + int64(t.interval)
+		}
+		w.setPreciseExpires(t, now)
+		return
+	}
+
+	jiffies := atomic.LoadUint64(&w.jiffies)
+	//从 slack 推迟之前的 expires 开始算, 推迟不会一个周期一个周期地累积
+	t.expires -= t.slacked
+	t.slacked = 0
+	switch t.periodMode() {
+	case FixedRate:
+		t.expires += t.period
+		t.deadline += int64(t.interval)
+	case SkipMissed:
+		t.expires += t.period
+		t.deadline += int64(t.interval)
+		var missed uint64
+		if t.expires < jiffies {
+			k := (jiffies - t.expires + t.period - 1) / t.period
+			t.expires += k * t.period
+			t.deadline += int64(k) * int64(t.interval)
+			missed = k
+		}
+		atomic.StoreUint64(&t.missed, missed)
+	default:
+		t.expires = t.period + jiffies
+		t.deadline = now + int64(t.interval)
+	}
+}
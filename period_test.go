@@ -0,0 +1,134 @@
+package timer
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPeriodModeRearm 测试周期 timer 在 callback 执行很慢时的三种重新调度方式。
+// 功能点：FixedDelay 从 callback 结束时再等一个周期；FixedRate 锚定原来的调度并报告落后的周期数；
+// SkipMissed 跳过已经错过的周期并报告跳过的数量。
+// 方法：手动推进时间轮，第一次 callback 阻塞期间把 jiffies 推进 35 个 tick 模拟慢 callback，
+// 检查重新调度后的 expires，再推进到第二次执行并检查 callback 中 Missed() 的值。
+func TestPeriodModeRearm(t *testing.T) {
+	const period = 10
+	tests := []struct {
+		mode          PeriodMode
+		secondExpires uint64
+		secondMissed  uint64
+	}{
+		{mode: FixedDelay, secondExpires: 45 + period, secondMissed: 0},
+		{mode: FixedRate, secondExpires: 20, secondMissed: 2},
+		{mode: SkipMissed, secondExpires: 50, secondMissed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			w := newIdleTestWheel(t)
+			started := make(chan uint64, 2)
+			release := make(chan struct{})
+
+			var tm *WheelTimer
+			tm = w.NewWheelTimerFunc(0, func(time.Time, ...interface{}) {
+				started <- tm.Missed()
+				<-release
+			})
+			if !tm.Stop() {
+				t.Fatalf("Stop returned false, expected true")
+			}
+			tm.SetPeriodMode(tt.mode)
+			tm.ResetTimer(period*w.tick, period*w.tick)
+
+			if got := advanceUntilFired(t, w, tm, 100); got != period {
+				t.Fatalf("first execution at jiffies %d, expected %d", got, period)
+			}
+			<-started
+			w.Lock()
+			atomic.StoreUint64(&w.jiffies, 45)
+			w.Unlock()
+			close(release)
+
+			requireEventually(t, 100*time.Millisecond, func() bool {
+				w.Lock()
+				defer w.Unlock()
+				return tm.list != nil
+			}, "periodic timer not re-armed")
+			if tm.expires != tt.secondExpires {
+				t.Fatalf("re-armed expires = %d, expected %d", tm.expires, tt.secondExpires)
+			}
+
+			advanceUntilFired(t, w, tm, 100)
+			if got := <-started; got != tt.secondMissed {
+				t.Fatalf("Missed() in second callback = %d, expected %d", got, tt.secondMissed)
+			}
+			requireEventually(t, 100*time.Millisecond, tm.Stop, "periodic timer did not stop")
+		})
+	}
+}
+
+// TestTickerWithPeriodMode 测试 ticker 选项设置周期模式。
+// 功能点：WithPeriodMode 应作用于 NewTicker 创建的内部 timer，ticker 仍然正常周期触发。
+// 方法：创建 FixedRate ticker，检查内部 timer 的 mode，并等待两次 tick。
+func TestTickerWithPeriodMode(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	ticker := w.NewTicker(5*testTick, WithPeriodMode(FixedRate))
+	if ticker.r.periodMode() != FixedRate {
+		t.Fatalf("ticker mode = %v, expected %v", ticker.r.periodMode(), FixedRate)
+	}
+	for i := 0; i < 2; i++ {
+		waitTime(t, ticker.C, 200*time.Millisecond, "FixedRate ticker")
+	}
+	requireEventually(t, 100*time.Millisecond, ticker.Stop, "ticker did not stop")
+	assertWheelEmpty(t, w)
+}
+
+// TestTickFuncMissedConcurrentRead 测试在 TickFunc 的回调中读取 Missed()。
+// 功能点：TickFunc 的 f 在新的 goroutine 中执行，读 Missed() 和 tick goroutine 重新调度时写 missed 不能有数据竞争。
+// 方法：FixedRate 和 SkipMissed 的 ticker 在回调中读 Missed()，运行若干个周期，配合 -race 检查。
+func TestTickFuncMissedConcurrentRead(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	for _, mode := range []PeriodMode{FixedRate, SkipMissed} {
+		fired := make(chan struct{}, 10)
+		created := make(chan struct{})
+		var ticker *Ticker
+		ticker = w.TickFunc(testTick, func() {
+			<-created
+			_ = ticker.Missed()
+			select {
+			case fired <- struct{}{}:
+			default:
+			}
+		}, WithPeriodMode(mode))
+		close(created)
+		for i := 0; i < 5; i++ {
+			waitStruct(t, fired, time.Second, mode.String()+" ticker")
+		}
+		requireEventually(t, 100*time.Millisecond, ticker.Stop, "ticker did not stop")
+	}
+}
+
+// TestSetPeriodModeWhileRunning 测试周期 timer 运行中修改周期模式。
+// 功能点：SetPeriodMode 持有锁并检查 generation，和 tick goroutine 重新调度时读 mode 没有数据竞争；
+// Handle.SetPeriodMode 在 Release 之后返回 ErrReleased。
+// 方法：ticker 运行期间反复调用 Ticker.SetPeriodMode 和 Handle.SetPeriodMode，配合 -race 检查，Release 后再通过 handle 修改。
+func TestSetPeriodModeWhileRunning(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	ticker := w.NewTicker(testTick)
+	h := ticker.Handle()
+	modes := []PeriodMode{FixedDelay, FixedRate, SkipMissed}
+	for i := 0; i < 3; i++ {
+		for _, mode := range modes {
+			ticker.SetPeriodMode(mode)
+			if err := h.SetPeriodMode(mode); err != nil {
+				t.Fatalf("Handle.SetPeriodMode() err = %v, expected nil", err)
+			}
+		}
+		waitTime(t, ticker.C, time.Second, "ticker")
+	}
+	requireEventually(t, 100*time.Millisecond, ticker.Stop, "ticker did not stop")
+	ticker.Release()
+	if err := h.SetPeriodMode(FixedRate); err != ErrReleased {
+		t.Fatalf("Handle.SetPeriodMode() after Release err = %v, expected ErrReleased", err)
+	}
+}
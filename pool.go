@@ -3,6 +3,7 @@ package timer
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type PoolNewCounter interface {
@@ -41,3 +42,86 @@ func (tp *timerSyncPool) Put(t *WheelTimer) {
 func (tp *timerSyncPool) PoolNewCount() int64 {
 	return atomic.LoadInt64(&tp.newCount)
 }
+
+// WithWrapperPool 开启后 Timer/Ticker 的外壳和缓冲为 1 的 channel 在调用者 Release 之后放回 pool,
+// 稳定状态下 NewTimer/AfterFunc/Stop/Release 不分配内存。
+// 代价是 Release 之后旧的 *Timer/*Ticker 和 C 可能已经被别的调用者复用, 不能再使用: 放回 pool 的外壳保留 r 和 gen,
+// 被复用之前旧外壳上的操作和没有开启时一样在持有锁时检查 generation 后失效, 被复用之后就是新调用者的 timer;
+// 需要在 Release 之后安全地操作旧 timer 时用 Handle。只有 Release 真正把 timer 放回 pool, 并且 callback 不在执行中时才复用,
+// 自动回收(WithAutoRelease)的一次性 timer 调用者可能还在读 C, 外壳和 channel 都不复用。
+// 复用的外壳包括 NewTimer/NewPreciseTimer/AfterFunc/NewTimerFunc 的 Timer 和 NewTicker/TickFunc/TickInfoFunc 的 Ticker;
+// NewEventTicker 的 EventTicker 和它的 TickEvent channel 不复用
+func WithWrapperPool() Option {
+	return func(w *Wheel) {
+		w.poolWrappers = true
+	}
+}
+
+var (
+	timerWrappers  = sync.Pool{New: func() interface{} { return new(Timer) }}
+	tickerWrappers = sync.Pool{New: func() interface{} { return new(Ticker) }}
+	timeChans      = sync.Pool{New: func() interface{} { return make(chan time.Time, 1) }}
+)
+
+func (w *Wheel) newTimerWrapper(r *timer, c chan time.Time) *Timer {
+	if !w.poolWrappers {
+		return &Timer{C: c, c: c, r: r, gen: r.gen}
+	}
+	t := timerWrappers.Get().(*Timer)
+	t.C, t.c = c, c
+	t.r, t.gen = r, r.gen
+	return t
+}
+
+func (w *Wheel) getTicker() *Ticker {
+	if !w.poolWrappers {
+		return new(Ticker)
+	}
+	return tickerWrappers.Get().(*Ticker)
+}
+
+func (w *Wheel) getTimeChan() chan time.Time {
+	if !w.poolWrappers {
+		return make(chan time.Time, 1)
+	}
+	return timeChans.Get().(chan time.Time)
+}
+
+// putTimeChan 取走 channel 里没有读的值再放回 pool
+func putTimeChan(c chan time.Time) {
+	if cap(c) != 1 {
+		return
+	}
+	select {
+	case <-c:
+	default:
+	}
+	timeChans.Put(c)
+}
+
+// releaseWrapped 是 Timer/Ticker 的 Release, 返回外壳和 channel 是否可以复用:
+// 开启了 WithWrapperPool, timer 真正放回了 pool, 并且没有正在执行的 callback 还会使用外壳和 channel。
+// debug 模式下和 timer 一样不复用
+func (t *timer) releaseWrapped(gen uint32) bool {
+	pooled := t.w.poolWrappers && !timerDebug
+	return t.release(gen) && pooled && atomic.LoadInt32(&t.busy) == 0
+}
+
+// recycle 把外壳和 channel 放回 pool。r 和 gen 保留下来: r 已经 Release, 旧外壳上的 Stop/Reset/Release 等
+// 检查 generation 后返回失败, 不会因为 r 为 nil 而 panic。channel 已经放回 pool, 置为 nil 避免读到别的 timer 的值
+func (t *Timer) recycle() {
+	if t.c != nil {
+		putTimeChan(t.c)
+	}
+	t.C, t.c = nil, nil
+	timerWrappers.Put(t)
+}
+
+func (t *Ticker) recycle() {
+	if t.c != nil {
+		putTimeChan(t.c)
+	}
+	t.C, t.c, t.s = nil, nil, nil
+	t.sender = tickSender{}
+	tickerWrappers.Put(t)
+}
@@ -0,0 +1,135 @@
+package timer
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestZeroAllocs 测试 WithWrapperPool 下稳定状态的内存分配。
+// 功能点：NewTimer/AfterFunc/NewPreciseTimer/TickInfoFunc/NewWheelTimerFunc 加上 Stop、Release 不分配内存：timer、外壳和 channel 都从 pool 中复用，
+// sendTime/goFunc 的参数放在 timer 自己的 argBuf 中。
+// 方法：tick 为 1 小时的时间轮上用 testing.AllocsPerRun 反复创建、Stop、Release。
+func TestZeroAllocs(t *testing.T) {
+	if timerDebug {
+		t.Skip("timerdebug 模式下 timer 不复用")
+	}
+	if raceEnabled {
+		t.Skip("race 模式下 sync.Pool 会随机丢弃对象")
+	}
+	w := newIdleTestWheel(t, WithWrapperPool())
+	f := func() {}
+	wf := func(time.Time, ...interface{}) {}
+	inf := func(FireInfo) {}
+
+	for _, c := range []struct {
+		name string
+		run  func()
+	}{
+		{name: "NewTimer", run: func() {
+			tm := w.NewTimer(time.Minute)
+			if !tm.Stop() {
+				t.Fatal("Stop() = false, expected true")
+			}
+			tm.Release()
+		}},
+		{name: "AfterFunc", run: func() {
+			tm := w.AfterFunc(time.Minute, f)
+			if !tm.Stop() {
+				t.Fatal("Stop() = false, expected true")
+			}
+			tm.Release()
+		}},
+		{name: "NewPreciseTimer", run: func() {
+			tm := w.NewPreciseTimer(time.Minute)
+			if !tm.Stop() {
+				t.Fatal("Stop() = false, expected true")
+			}
+			tm.Release()
+		}},
+		{name: "TickInfoFunc", run: func() {
+			tk := w.TickInfoFunc(time.Minute, inf)
+			tk.Stop()
+			tk.Release()
+		}},
+		{name: "NewWheelTimerFunc", run: func() {
+			tm := w.NewWheelTimerFunc(time.Minute, wf)
+			if !tm.Stop() {
+				t.Fatal("Stop() = false, expected true")
+			}
+			tm.Release()
+		}},
+	} {
+		c.run() //先让 pool 中有对象
+		if n := testing.AllocsPerRun(1000, c.run); n != 0 {
+			t.Errorf("%s + Stop + Release allocs = %v, expected 0", c.name, n)
+		}
+	}
+	assertWheelEmpty(t, w)
+}
+
+// TestWrapperPoolDrainsChannel 测试复用的 channel。
+// 功能点：到期之后没有读的 channel 在 Release 时被清空，复用这个 channel 的新 timer 的 C 中没有旧的值；
+// 没有开启 WithWrapperPool 时 Release 之后旧的 *Timer 保持不变。
+// 方法：手动推进时间轮让 timer 到期但不读 C，等 callback 执行完后 Release，再创建新 timer 检查 C 为空。
+func TestWrapperPoolDrainsChannel(t *testing.T) {
+	w := newIdleTestWheel(t, WithWrapperPool())
+	for i := 0; i < 10; i++ {
+		tm := w.NewTimer(0)
+		r := tm.r
+		w.onTick()
+		requireEventually(t, time.Second, func() bool {
+			return len(tm.C) == 1 && atomic.LoadInt32(&r.busy) == 0
+		}, "timer not fired")
+		tm.Release()
+
+		fresh := w.NewTimer(time.Hour)
+		if n := len(fresh.C); n != 0 {
+			t.Fatalf("reused channel has %d stale values, expected 0", n)
+		}
+		fresh.Stop()
+		fresh.Release()
+	}
+
+	plain := newIdleTestWheel(t)
+	tm := plain.NewTimer(time.Hour)
+	tm.Stop()
+	tm.Release()
+	if tm.r == nil || tm.C == nil {
+		t.Fatal("Timer reset by Release without WithWrapperPool")
+	}
+}
+
+// TestRecycledWrapperFailsSafely 测试放回 pool 的外壳。
+// 功能点：WithWrapperPool 下 Release 之后外壳被复用之前，旧的 *Timer/*Ticker 上的 Stop/Reset/Release/SetSlack/Info
+// 检查 generation 后失效，不会因为外壳被清空而 panic，也不会影响之后创建的 timer。
+// 方法：创建、Stop、Release 之后直接在旧外壳上调用各个方法，再检查时间轮为空。
+func TestRecycledWrapperFailsSafely(t *testing.T) {
+	w := newIdleTestWheel(t, WithWrapperPool())
+
+	tm := w.NewTimer(time.Hour)
+	tm.Stop()
+	tm.Release()
+	if tm.Stop() || tm.Reset(time.Millisecond) {
+		t.Fatal("Stop/Reset of recycled Timer returned true, expected false")
+	}
+	tm.SetSlack(time.Second)
+	tm.Release()
+	if got := tm.Info(); got != ErrReleased.Error() {
+		t.Fatalf("Info() = %q, expected %q", got, ErrReleased.Error())
+	}
+
+	tk := w.TickFunc(time.Hour, func() {})
+	tk.Stop()
+	tk.Release()
+	if tk.Stop() {
+		t.Fatal("Stop of recycled Ticker returned true, expected false")
+	}
+	tk.Reset(time.Millisecond)
+	tk.SetSlack(time.Second)
+	tk.Release()
+	if got := tk.Dropped(); got != 0 {
+		t.Fatalf("Dropped() = %d, expected 0", got)
+	}
+	assertWheelEmpty(t, w)
+}
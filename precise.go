@@ -0,0 +1,131 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// 精确模式(hybrid precision):
+// 普通 timer 的 expires 向上取整到 tick, 同一个槽里的 timer 按插入顺序执行, 误差最多 2 个 tick。
+// precise timer 的 expires 向下取整, 保证在 deadline 之前就被 onTick 从 tv1 取出, 取出后按 deadline
+// 排序放到 w.precise 队列, 再由一个 runtime timer 在 deadline 时派发, 从而得到低于 tick 的精度。
+// 只有少量需要精度的 timer 用这个模式, 其他 timer 仍然走时间轮。
+// 所有时间都从 w.now() 取; ManualWheel/SimWheel(w.single)使用虚拟时间, 不启动 runtime timer,
+// precise 队列由 Advance 按 deadline 派发。
+
+// preciseTicks 返回精确模式下 timer 放进时间轮的 tick 数: 保证 onTick 取出 timer 的时间不晚于 deadline。
+// 下一次 onTick 最多在一个 tick 后发生, 所以比 d/tick 再少一个 tick。
+func preciseTicks(d, tick time.Duration) uint64 {
+	if d < 2*tick {
+		return 0
+	}
+	return uint64(d/tick) - 1
+}
+
+func (w *Wheel) setPreciseExpires(t *timer, now int64) {
+	tick, seq := w.curTick()
+	t.expires = atomic.LoadUint64(&w.jiffies) + preciseTicks(time.Duration(t.deadline-now), tick)
+	t.tickSeq = seq
+}
+
+// addPrecise 把 timer 按 deadline 插入 precise 队列, 需要持有 w.Lock()
+func (w *Wheel) addPrecise(t *timer) {
+	b := w.precise.Back()
+	for b != nil && b.deadline > t.deadline {
+		b = b.Prev()
+	}
+	if b == nil {
+		w.precise.PushFront(t)
+	} else {
+		w.precise.InsertAfter(b, t)
+	}
+	w.preciseTimers++
+	t.list = &w.precise
+	t.state = NotReady
+
+	if w.precise.Front() == t {
+		w.armPrecise(time.Duration(t.deadline - w.now().UnixNano()))
+	}
+}
+
+func (w *Wheel) armPrecise(d time.Duration) {
+	if w.close || w.single {
+		return
+	}
+	if w.preciseTimer == nil {
+		w.preciseTimer = time.AfterFunc(d, w.runPrecise)
+		return
+	}
+	w.preciseTimer.Reset(d)
+}
+
+// runPrecise 由 preciseTimer 触发(单 goroutine 模式下由 Advance 调用), 执行 precise 队列里所有已经到期的 timer
+func (w *Wheel) runPrecise() {
+	w.Lock()
+	now := w.now().UnixNano()
+	var execList timerList
+	for t := w.precise.Front(); t != nil && t.deadline <= now; t = w.precise.Front() {
+		w.unlink(t)
+		if !w.pullLocked(t) {
+			t.Entry.Reset()
+			continue
+		}
+		execList.PushBack(t)
+		t.state = Ready
+		atomic.StoreInt32(&t.busy, 1)
+		t.list = nil
+		w.untrack(t)
+	}
+	if head := w.precise.Front(); head != nil {
+		w.armPrecise(time.Duration(head.deadline - now))
+	}
+	w.Unlock()
+
+	if !execList.Empty() {
+		atomic.AddInt32(&w.taskRuning, 1)
+		w.runList(execList)
+	}
+}
+
+func (w *Wheel) stopPrecise() {
+	w.Lock()
+	if w.preciseTimer != nil {
+		w.preciseTimer.Stop()
+	}
+	w.Unlock()
+}
+
+func (w *Wheel) newPreciseTimer(when time.Duration, period time.Duration,
+	f func(time.Time, ...interface{}), arg ...interface{}) *timer {
+	return w.markPrecise(w.newTimer(when, period, f, arg...))
+}
+
+// markPrecise 把新建的 t 设为精确模式, 按当前时间重新计算精确的到期时间
+func (w *Wheel) markPrecise(t *timer) *timer {
+	t.precise = true
+	w.setPreciseExpires(t, w.now().UnixNano())
+	return t
+}
+
+// NewPreciseTimerFunc 和 NewWheelTimerFunc 一样, 但使用精确模式, 在 deadline 时执行 callback 而不是向上取整到 tick。
+// 精确模式需要额外的 runtime timer 派发, 只适合少量对精度敏感的 timer。
+func (w *Wheel) NewPreciseTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	t := w.newPreciseTimer(d, 0, f, arg...)
+	if w.addTimer(t) {
+		return t
+	}
+
+	return nil
+}
+
+// NewPreciseTimer 是精确模式的 NewTimer
+func (w *Wheel) NewPreciseTimer(d time.Duration) *Timer {
+	c := w.getTimeChan()
+	t := w.newTimerWrapper(w.markPrecise(w.newTimerArg(d, 0, sendTime, c)), c)
+
+	if w.addTimer(t.r) {
+		return t
+	}
+
+	return nil
+}
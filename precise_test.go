@@ -0,0 +1,113 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPreciseTimerFiresWithinTick 测试精确模式的触发精度。
+// 功能点：precise timer 不向上取整到 tick，小于一个 tick 和不对齐 tick 的 duration 都应在 deadline 触发，
+// 早于同样 duration 的普通 timer 所在的下一个 tick。
+// 方法：在 20ms tick 的 SimWheel 上分别创建 5ms、25ms、47ms 的 precise timer 和普通 timer，
+// 用虚拟时间检查 precise timer 在 start+d 执行，普通 timer 在之后的 tick 边界执行，并且执行顺序是先 precise 后普通。
+func TestPreciseTimerFiresWithinTick(t *testing.T) {
+	const tick = 20 * time.Millisecond
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(tick, start)
+
+	type fire struct {
+		precise bool
+		d       time.Duration
+		at      time.Duration
+	}
+	var fired []fire
+	record := func(_ time.Time, args ...interface{}) {
+		fired = append(fired, fire{precise: args[0].(bool), d: args[1].(time.Duration), at: sim.Now().Sub(start)})
+	}
+	durations := []time.Duration{5 * time.Millisecond, 25 * time.Millisecond, 47 * time.Millisecond}
+	for _, d := range durations {
+		sim.NewPreciseTimerFunc(d, record, true, d)
+		sim.NewWheelTimerFunc(d, record, false, d)
+	}
+	sim.RunUntilIdle()
+
+	if len(fired) != 2*len(durations) {
+		t.Fatalf("%d timers fired, expected %d", len(fired), 2*len(durations))
+	}
+	coarse := make(map[time.Duration]time.Duration)
+	for i, f := range fired {
+		if i > 0 && f.at < fired[i-1].at {
+			t.Fatalf("timer %d fired at %s before previous timer at %s", i, f.at, fired[i-1].at)
+		}
+		if !f.precise {
+			coarse[f.d] = f.at
+		}
+	}
+	for _, f := range fired {
+		if !f.precise {
+			continue
+		}
+		if f.at != f.d {
+			t.Fatalf("precise timer of %s fired at %s, expected exactly %s", f.d, f.at, f.d)
+		}
+		next := (f.d/tick + 1) * tick
+		if c, ok := coarse[f.d]; !ok || c < next || f.at >= next {
+			t.Fatalf("precise timer of %s fired at %s, coarse timer at %s, expected precise before the next tick %s", f.d, f.at, c, next)
+		}
+	}
+}
+
+// TestPreciseTimersOrderedByDeadline 测试 precise 队列按 deadline 排序。
+// 功能点：同一个 tick 内到期的 precise timer 应按 deadline 顺序执行，而不是插入顺序。
+// 方法：按 deadline 递减的顺序创建三个 precise timer，收集 callback 的执行顺序。
+func TestPreciseTimersOrderedByDeadline(t *testing.T) {
+	w := newTestWheel(t, 20*time.Millisecond)
+	fired := make(chan int, 3)
+
+	for i, d := range []time.Duration{9 * time.Millisecond, 6 * time.Millisecond, 3 * time.Millisecond} {
+		w.NewPreciseTimerFunc(d, func(_ time.Time, args ...interface{}) {
+			fired <- args[0].(int)
+		}, i)
+	}
+
+	for _, expected := range []int{2, 1, 0} {
+		select {
+		case got := <-fired:
+			if got != expected {
+				t.Fatalf("precise timer %d fired, expected %d", got, expected)
+			}
+		case <-time.After(200 * time.Millisecond):
+			t.Fatalf("timed out waiting for precise timer %d", expected)
+		}
+	}
+	assertWheelEmpty(t, w)
+}
+
+// TestPreciseTimerStopAndReset 测试 precise timer 的 Stop 和周期 Reset。
+// 功能点：已经进入 precise 队列的 timer 可以 Stop；Reset 成周期 timer 后应持续触发。
+// 方法：创建不足一个 tick 的 precise timer 后立即 Stop，验证不触发；再 ResetTimer 为周期 timer，等待多次触发后停止。
+func TestPreciseTimerStopAndReset(t *testing.T) {
+	w := newTestWheel(t, 20*time.Millisecond)
+	fired := make(chan time.Time, 8)
+
+	timer := w.NewPreciseTimerFunc(5*time.Millisecond, func(tm time.Time, _ ...interface{}) {
+		fired <- tm
+	})
+	if !timer.Stop() {
+		t.Fatalf("Stop of queued precise timer returned false, expected true")
+	}
+	assertNoTime(t, fired, 30*time.Millisecond, "stopped precise timer")
+
+	if !timer.ResetTimer(5*time.Millisecond, 5*time.Millisecond) {
+		t.Fatalf("ResetTimer of stopped precise timer returned false, expected true")
+	}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		waitTime(t, fired, 200*time.Millisecond, "periodic precise timer")
+	}
+	if took := time.Since(start); took >= 40*time.Millisecond {
+		t.Fatalf("3 periods of 5ms took %s, expected period not rounded up to 20ms tick", took)
+	}
+	requireEventually(t, 100*time.Millisecond, timer.Stop, "periodic precise timer did not stop")
+	assertWheelEmpty(t, w)
+}
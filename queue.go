@@ -0,0 +1,172 @@
+package timer
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// WithQueuedAdd 模式下 timer 的 qs 状态, 用 atomic 读写, 在 add/Stop 和 tick 之间仲裁。
+// qs 的低 32 位是状态, 高 32 位是 t.gen, 无锁 add/Stop 的 CAS 同时比较 generation:
+// 检查 handle 之后 timer 被 Release 并且被别人复用, 旧 handle 的 CAS 也不会成功
+const (
+	qsNone     int32 = iota //不在时间轮也不在队列中
+	qsQueued                //add 已经放入队列, 还没加入时间轮
+	qsArmed                 //已经加入时间轮
+	qsStopping              //已经 Stop, 还在时间轮中, 等 drain 移除
+)
+
+func qsWord(gen uint32, s int32) uint64 {
+	return uint64(gen)<<32 | uint64(uint32(s))
+}
+
+func (t *timer) casQs(gen uint32, old, new int32) bool {
+	return atomic.CompareAndSwapUint64(&t.qs, qsWord(gen, old), qsWord(gen, new))
+}
+
+// setQs 调用者持有 w.Lock, t.gen 不会变化
+func (t *timer) setQs(s int32) {
+	atomic.StoreUint64(&t.qs, qsWord(atomic.LoadUint32(&t.gen), s))
+}
+
+func (t *timer) loadQs() int32 {
+	return int32(uint32(atomic.LoadUint64(&t.qs)))
+}
+
+// WithQueuedAdd 开启无锁 add/Stop: 新建 timer 和 Stop 不再竞争 w.Lock, 而是放到 MPSC 队列中,
+// 由 tick goroutine 处理到期的槽之前统一加入/移除。
+// 还没加入时间轮就被 Stop 的 timer 不会再加入; 入队时已经到期的 timer 在下一个 tick 执行。
+// Reset、Release、StopWait 以及开启了 WithMaxTimers/Group 配额的 add 仍然加锁, 会先处理队列
+func WithQueuedAdd() Option {
+	return func(w *Wheel) {
+		w.queue = newOpQueue()
+	}
+}
+
+type qop struct {
+	next *qop
+	t    *timer
+	seq  uint32 //入队时的 t.qseq, 和当前值不同说明这个操作已经过期
+	stop bool
+}
+
+// opQueue 是多生产者单消费者的无锁队列, 按 P 分成多个栈减少 CAS 竞争。
+// 消费者一次取走整个栈再反转, 没有 ABA 问题; 同一个 timer 在一次 drain 中最多有一个有效的操作, 栈之间不需要保证顺序
+type opQueue struct {
+	stripes []opStripe
+}
+
+type opStripe struct {
+	head unsafe.Pointer //*qop
+	_    [56]byte       //avoid false sharing
+}
+
+func newOpQueue() *opQueue {
+	return &opQueue{stripes: make([]opStripe, runtime.GOMAXPROCS(0))}
+}
+
+func (q *opQueue) push(op *qop) {
+	s := &q.stripes[GetPid()%len(q.stripes)]
+	for {
+		head := atomic.LoadPointer(&s.head)
+		op.next = (*qop)(head)
+		if atomic.CompareAndSwapPointer(&s.head, head, unsafe.Pointer(op)) {
+			return
+		}
+	}
+}
+
+// take 按入队顺序返回第 i 个栈中全部的操作
+func (q *opQueue) take(i int) *qop {
+	s := &q.stripes[i]
+	if atomic.LoadPointer(&s.head) == nil {
+		return nil
+	}
+	op := (*qop)(atomic.SwapPointer(&s.head, nil))
+	var list *qop
+	for op != nil {
+		next := op.next
+		op.next = list
+		list = op
+		op = next
+	}
+	return list
+}
+
+// enqueueAdd 无锁加入 t, t 的字段在入队前已经设置好, 由 qs 的 CAS 保证 tick goroutine 看到
+func (w *Wheel) enqueueAdd(t *timer, gen uint32, checkClose bool) error {
+	if checkClose {
+		select {
+		case <-w.quit:
+			return ErrWheelStopped
+		default:
+		}
+	}
+	if !t.casQs(gen, qsNone, qsQueued) {
+		if !t.live(gen) {
+			return ErrTimerReleased
+		}
+		w.violation(fmt.Errorf("%w: repeat addTimer, timer still in wheel", ErrTimerActive))
+		return ErrTimerActive
+	}
+	seq := atomic.AddUint32(&t.qseq, 1)
+	w.queue.push(&qop{t: t, seq: seq})
+	return nil
+}
+
+// dequeueStop 无锁 Stop, ok 为 false 时需要走加锁的流程
+func (w *Wheel) dequeueStop(t *timer, gen uint32) (ok bool) {
+	if t.casQs(gen, qsQueued, qsNone) {
+		//还在队列中, drain 时会跳过
+		return true
+	}
+	if t.casQs(gen, qsArmed, qsStopping) {
+		//tick 取出到期 timer 时会发现它已经 Stop, 不再执行
+		w.queue.push(&qop{t: t, seq: atomic.LoadUint32(&t.qseq), stop: true})
+		return true
+	}
+	return false
+}
+
+// drainLocked 把队列中的 add/Stop 应用到时间轮, 调用者持有 w.Lock
+func (w *Wheel) drainLocked() {
+	if w.queue == nil {
+		return
+	}
+	for i := range w.queue.stripes {
+		for op := w.queue.take(i); op != nil; op = op.next {
+			w.applyLocked(op)
+		}
+	}
+}
+
+func (w *Wheel) applyLocked(op *qop) {
+	t := op.t
+	if atomic.LoadUint32(&t.qseq) != op.seq {
+		return
+	}
+	if op.stop {
+		if t.loadQs() == qsStopping {
+			w.delLocked(t)
+		}
+		return
+	}
+	if t.casQs(atomic.LoadUint32(&t.gen), qsQueued, qsArmed) {
+		if err := w.addLocked(t); err != nil {
+			w.log.Errorf("apply queued timer:%s fail, err:%v", t.Info(), err)
+		}
+	}
+}
+
+// pullLocked tick 取出到期的 t, 返回 false 表示 t 已经被无锁 Stop, 调用者持有 w.Lock
+func (w *Wheel) pullLocked(t *timer) bool {
+	if w.queue == nil || t.casQs(atomic.LoadUint32(&t.gen), qsArmed, qsNone) {
+		return true
+	}
+	t.list = nil
+	t.state = Stoped
+	t.setQs(qsNone)
+	w.untrack(t)
+	return false
+}
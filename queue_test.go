@@ -0,0 +1,88 @@
+package timer
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestQueuedAddStopBeforeApply 测试 WithQueuedAdd 模式下还没加入时间轮就被 Stop 的 timer。
+// 功能点：Stop 返回 true，drain 之后 timer 不在时间轮中也不会执行，可以正常 Release；已经加入时间轮的 timer Stop 后由 drain 移除。
+// 方法：tick 为 1 小时的时间轮上创建 timer，分别在 drain 前后 Stop，检查 Timers()/RealTimers() 和 Release。
+func TestQueuedAddStopBeforeApply(t *testing.T) {
+	w := newIdleTestWheel(t, WithQueuedAdd())
+	f := func(time.Time, ...interface{}) { t.Error("stopped timer fired") }
+
+	tm := w.NewWheelTimerFunc(time.Hour, f)
+	if !tm.Stop() {
+		t.Fatal("Stop() of queued timer = false, expected true")
+	}
+	if n := w.Timers(); n != 0 {
+		t.Fatalf("Timers() = %d, expected 0", n)
+	}
+	if err := tm.TryRelease(); err != nil {
+		t.Fatalf("TryRelease() err = %v, expected nil", err)
+	}
+
+	tm = w.NewWheelTimerFunc(time.Hour, f)
+	if n := w.Timers(); n != 1 {
+		t.Fatalf("Timers() after drain = %d, expected 1", n)
+	}
+	if !tm.Stop() {
+		t.Fatal("Stop() of armed timer = false, expected true")
+	}
+	if err := tm.TryRelease(); err != nil {
+		t.Fatalf("TryRelease() after Stop err = %v, expected nil", err)
+	}
+	if n := w.RealTimers(); n != 0 {
+		t.Fatalf("RealTimers() = %d, expected 0", n)
+	}
+}
+
+// TestQueuedAddCurrentTick 测试入队时已经到期的 timer。
+// 功能点：drain 在处理当前槽之前进行，入队时已经到期的 timer 在这次 onTick 执行，而不是等时间轮转一圈。
+// 方法：手动驱动 onTick，入队一个 0 延迟的 timer 后调用一次 onTick，检查 callback 执行。
+func TestQueuedAddCurrentTick(t *testing.T) {
+	w := newIdleTestWheel(t, WithQueuedAdd())
+	fired := make(chan struct{}, 1)
+	w.NewWheelTimerFunc(0, func(time.Time, ...interface{}) {
+		fired <- struct{}{}
+	})
+	w.onTick()
+	waitStruct(t, fired, 200*time.Millisecond, "queued timer due in current tick")
+}
+
+// TestQueuedAddConcurrent 测试并发 add/Stop。
+// 功能点：并发创建和 Stop timer 时，Stop 成功的 timer 不会执行，没有 Stop 成功的 timer 都会执行，最后时间轮为空。
+// 方法：多个 goroutine 创建短 timer 并随机 Stop，统计 Stop 成功和执行的次数之和。
+func TestQueuedAddConcurrent(t *testing.T) {
+	w := newTestWheel(t, time.Millisecond, WithQueuedAdd(), WithLogger(benchDiscardLogger{}))
+	const workers, per = 8, 500
+	var fired, stopped int64
+	f := func(time.Time, ...interface{}) { atomic.AddInt64(&fired, 1) }
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < per; j++ {
+				tm := w.NewWheelTimerFunc(time.Duration(j%5)*time.Millisecond, f)
+				if (i+j)%2 == 0 && tm.Stop() {
+					atomic.AddInt64(&stopped, 1)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	requireEventually(t, time.Second, func() bool {
+		return atomic.LoadInt64(&fired)+atomic.LoadInt64(&stopped) == workers*per
+	}, "fired + stopped != total")
+	if n := w.Timers(); n != 0 {
+		t.Fatalf("Timers() = %d, expected 0", n)
+	}
+	if n := w.RealTimers(); n != 0 {
+		t.Fatalf("RealTimers() = %d, expected 0", n)
+	}
+}
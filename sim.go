@@ -0,0 +1,41 @@
+package timer
+
+import "time"
+
+// SimWheel 是离散事件模拟用的时间轮: 虚拟时间直接跳到下一个有 timer 到期的槽, 不需要真的等待,
+// 同一个槽里的 timer 按 deadline 依次执行, 结果是确定的。
+// SimWheel 嵌入了 *Wheel, 基于 Wheel 写的业务代码可以直接拿 sim.Wheel 做模拟;
+// AfterFunc/TickFunc 的 f 也在当前 goroutine 中执行。channel timer 需要在 callback 之外读, 不能阻塞等待。
+// 和 ManualWheel 一样只能在一个 goroutine 中使用
+type SimWheel struct {
+	*Wheel
+	m *ManualWheel
+}
+
+// NewSimWheel 创建虚拟时间从 start 开始的 SimWheel
+func NewSimWheel(tick time.Duration, start time.Time, opts ...Option) *SimWheel {
+	m := NewManualWheel(tick, start, opts...)
+	m.sorted = true
+	return &SimWheel{Wheel: m.w, m: m}
+}
+
+// Now 返回当前的虚拟时间
+func (s *SimWheel) Now() time.Time {
+	return s.m.Now()
+}
+
+// RunUntil 执行到期时间不晚于 t 的所有 timer, 然后把虚拟时间设为 t
+func (s *SimWheel) RunUntil(t time.Time) {
+	s.m.Advance(t)
+}
+
+// RunUntilIdle 一直执行到时间轮中没有 timer 为止, 有周期 timer 时需要先 Stop, 否则不会返回
+func (s *SimWheel) RunUntilIdle() {
+	for {
+		next, ok := s.m.NextDeadline()
+		if !ok {
+			return
+		}
+		s.m.Advance(next)
+	}
+}
@@ -0,0 +1,147 @@
+package timer
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestSimWheelDeadlineOrder 测试模拟时间轮的执行顺序。
+// 功能点：同一个槽里的 timer 按 deadline 执行，而不是按加入的顺序；AfterFunc 的 f 也在当前 goroutine 中执行。
+// 方法：tick 为 10ms，按 deadline 倒序加入同一个槽的 timer，RunUntilIdle 后检查执行顺序。
+func TestSimWheelDeadlineOrder(t *testing.T) {
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(10*time.Millisecond, start)
+	defer sim.Stop()
+
+	var order []time.Duration
+	for _, d := range []time.Duration{19, 15, 12, 11} {
+		d := d * time.Millisecond
+		sim.AfterFunc(d, func() { order = append(order, d) })
+	}
+	sim.RunUntilIdle()
+
+	want := []time.Duration{11, 12, 15, 19}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, expected %v ms", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i]*time.Millisecond {
+			t.Fatalf("order = %v, expected %v ms", order, want)
+		}
+	}
+}
+
+// TestSimWheelRunUntil 测试 RunUntil 的虚拟时间。
+// 功能点：RunUntil 只执行到期时间不晚于 t 的 timer，返回后 Now() 等于 t；callback 中 Now() 是 timer 到期的时间。
+// 方法：加入 1h 和 3h 的 timer，RunUntil 2h 后检查执行结果和 Now()。
+func TestSimWheelRunUntil(t *testing.T) {
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(time.Millisecond, start)
+	defer sim.Stop()
+
+	var fired []time.Time
+	f := func(now time.Time, _ ...interface{}) { fired = append(fired, now) }
+	sim.NewWheelTimerFunc(time.Hour, f)
+	sim.NewWheelTimerFunc(3*time.Hour, f)
+
+	sim.RunUntil(start.Add(2 * time.Hour))
+	if len(fired) != 1 {
+		t.Fatalf("fired %d timers before 2h, expected 1", len(fired))
+	}
+	if d := fired[0].Sub(start); d < time.Hour || d > time.Hour+2*time.Millisecond {
+		t.Fatalf("first timer fired at %v, expected about 1h", d)
+	}
+	if now := sim.Now(); !now.Equal(start.Add(2 * time.Hour)) {
+		t.Fatalf("Now() = %v, expected 2h", now.Sub(start))
+	}
+	sim.RunUntilIdle()
+	if len(fired) != 2 {
+		t.Fatalf("fired %d timers, expected 2", len(fired))
+	}
+}
+
+// TestSimWheelDays 测试模拟很长的虚拟时间。
+// 功能点：模拟多天的 timer 时虚拟时间直接跳到下一个到期的槽，很快执行完；基于 *Wheel 的代码可以直接在模拟时间轮上运行；
+// callback 按时间顺序执行，并且不早于请求的到期时间。
+// 方法：1ms tick 上加入随机分布在 10 天内的 timer，其中一部分在 callback 里继续加入新的 timer，RunUntilIdle 后检查执行次数和顺序。
+func TestSimWheelDays(t *testing.T) {
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(time.Millisecond, start)
+	defer sim.Stop()
+
+	rnd := rand.New(rand.NewSource(1))
+	const timers = 20000
+	fired := 0
+	var last time.Time
+	var schedule func(w *Wheel, d time.Duration, again int)
+	schedule = func(w *Wheel, d time.Duration, again int) {
+		due := sim.Now().Add(d)
+		w.AfterFunc(d, func() {
+			now := sim.Now()
+			if now.Before(due) || now.Before(last) {
+				t.Fatalf("fired at %v, due %v, last %v", now.Sub(start), due.Sub(start), last.Sub(start))
+			}
+			last = now
+			fired++
+			if again > 0 {
+				schedule(w, time.Duration(rnd.Int63n(int64(24*time.Hour))), again-1)
+			}
+		})
+	}
+	want := 0
+	for i := 0; i < timers; i++ {
+		schedule(sim.Wheel, time.Duration(rnd.Int63n(int64(10*24*time.Hour))), i%3)
+		want += 1 + i%3
+	}
+
+	begin := time.Now()
+	sim.RunUntilIdle()
+	if fired != want {
+		t.Fatalf("fired = %d, expected %d", fired, want)
+	}
+	if took := time.Since(begin); took > 5*time.Second {
+		t.Fatalf("simulating 10 days took %v", took)
+	}
+	t.Logf("simulated %v in %v", last.Sub(start), time.Since(begin))
+}
+
+// TestSimWheelPreciseTimers 测试虚拟时间下的精确模式。
+// 功能点：SimWheel/ManualWheel 上的 precise timer 不启动 runtime timer，由 RunUntil/Advance 在 deadline 的虚拟时间执行，
+// 和普通 timer 按时间顺序交错；NextDeadline 在 timer 进入 precise 队列后返回它的 deadline；callback 中新加的 precise timer 按当前虚拟时间计算。
+// 方法：10ms tick 上加入不对齐 tick 的 precise timer 和普通 timer，RunUntilIdle 后检查每个 callback 的虚拟时间和执行顺序。
+func TestSimWheelPreciseTimers(t *testing.T) {
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(10*time.Millisecond, start)
+	defer sim.Stop()
+
+	var fired []time.Duration
+	f := func(now time.Time, _ ...interface{}) { fired = append(fired, now.Sub(start)) }
+	sim.NewPreciseTimerFunc(3*time.Millisecond, f)
+	sim.NewPreciseTimerFunc(25*time.Millisecond, f)
+	sim.NewWheelTimerFunc(25*time.Millisecond, f)
+	sim.NewPreciseTimerFunc(47*time.Millisecond, func(now time.Time, arg ...interface{}) {
+		f(now, arg...)
+		sim.NewPreciseTimerFunc(5*time.Millisecond, f)
+	})
+	sim.NewPreciseTimerFunc(time.Hour+3*time.Millisecond, f)
+
+	sim.RunUntil(start.Add(20 * time.Millisecond))
+	if next, ok := sim.m.NextDeadline(); !ok || next.Sub(start) != 25*time.Millisecond {
+		t.Fatalf("NextDeadline() = %v, %v, expected 25ms", next.Sub(start), ok)
+	}
+	sim.RunUntilIdle()
+
+	want := []time.Duration{3, 25, 40, 47, 52, 3600003}
+	if len(fired) != len(want) {
+		t.Fatalf("fired at %v, expected %v ms", fired, want)
+	}
+	for i := range want {
+		if fired[i] != want[i]*time.Millisecond {
+			t.Fatalf("fired at %v, expected %v ms", fired, want)
+		}
+	}
+	if sim.preciseTimer != nil {
+		t.Fatal("virtual clock wheel started a runtime timer for precise timers")
+	}
+}
@@ -0,0 +1,336 @@
+package timer
+
+import (
+	"sync"
+	"time"
+)
+
+// SlabWheel 是 timer 存放在不含指针的 slab 数组中的时间轮: timer 用 32 位下标加 generation 表示(SlabTimer),
+// 链表用下标链接, callback 通过 RegisterHandler 注册的 handler 表查找, 每个 timer 只带一个 uint64 参数。
+// slab 数组里没有指针, GC 标记阶段不需要扫描大量 pending timer, 也没有 sync.Pool 和闭包的分配,
+// 适合 timer 数量很多、callback 种类很少的场景(比如百万连接的超时)。
+// 层级结构和槽位的计算、cascade 的顺序和 Wheel 共用(见 geometry), 只是链表的存储方式不同;
+// 功能比 Wheel 少: 没有 precise/ctx/group/slack 等, callback 在 tick goroutine 中依次执行, 不能阻塞
+type SlabWheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	jiffies uint64
+	geometry
+
+	//lists[0:1<<rootBits] 是 tv1, 之后每 1<<levelBits 个是一层, 最后一个是 overflow
+	lists  []slabList
+	chunks [][]slabTimer //每个 chunk slabChunk 个 timer, 扩容时不需要复制已有的 timer
+	free   uint32        //空闲的 timer 用 next 串起来, 0 表示没有
+	timers int
+
+	handlers []func(now time.Time, data uint64)
+	fired    []slabFire //onTick 中到期的 timer, 复用
+
+	quit chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// SlabTimer 是 SlabWheel 中 timer 的引用: 低 32 位是下标, 高 32 位是 generation。
+// timer 到期(一次性的)或者被 Stop 之后 generation 加 1, 旧的 SlabTimer 不会操作到复用这个下标的新 timer。
+// 0 不是有效的 SlabTimer
+type SlabTimer uint64
+
+// SlabHandler 是 RegisterHandler 返回的 handler 编号
+type SlabHandler uint32
+
+const (
+	slabChunkBits = 12
+	slabChunk     = 1 << slabChunkBits
+	slabNoList    = ^uint32(0)
+)
+
+// slabTimer 不含指针, 下标 0 保留, 作为链表的 nil
+type slabTimer struct {
+	next    uint32
+	prev    uint32
+	gen     uint32
+	list    uint32 //所在的链表, slabNoList 表示空闲
+	handler uint32
+	expires uint64
+	period  uint64
+	data    uint64
+}
+
+type slabList struct {
+	head, tail uint32
+}
+
+type slabFire struct {
+	handler uint32
+	data    uint64
+}
+
+// NewSlabWheel 创建 SlabWheel 并启动 tick goroutine。
+// opts 中只有 WithGeometry 对 SlabWheel 有效, 其他 Option 设置的是 Wheel 才有的功能, 会被忽略
+func NewSlabWheel(tick time.Duration, opts ...Option) *SlabWheel {
+	s := newSlabWheel(tick, opts...)
+	go s.run()
+	return s
+}
+
+func newSlabWheel(tick time.Duration, opts ...Option) *SlabWheel {
+	if tick <= 0 {
+		panic("tick must be greater than 0")
+	}
+	var cfg Wheel
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	g := cfg.geometry
+	if g.levels == 0 {
+		g = defaultGeometry()
+	}
+	return &SlabWheel{
+		tick:     tick,
+		geometry: g,
+		lists:    make([]slabList, 1<<g.rootBits+uint64(g.levels-1)<<g.levelBits+1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// RegisterHandler 注册 callback, 返回的编号在 AddTimer 中使用。f 在 tick goroutine 中执行, 不能阻塞
+func (s *SlabWheel) RegisterHandler(f func(now time.Time, data uint64)) SlabHandler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, f)
+	return SlabHandler(len(s.handlers) - 1)
+}
+
+// AddTimer 加入一个 d 之后到期的 timer, 到期时执行 h(now, data); period 大于 0 时之后每 period 执行一次。
+// h 没有注册时返回 ErrUnknownHandler, SlabWheel 已经 Stop 时返回 ErrWheelStopped
+func (s *SlabWheel) AddTimer(d, period time.Duration, h SlabHandler, data uint64) (SlabTimer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if int(h) >= len(s.handlers) {
+		return 0, ErrUnknownHandler
+	}
+	select {
+	case <-s.quit:
+		return 0, ErrWheelStopped
+	default:
+	}
+	idx := s.alloc()
+	r := s.at(idx)
+	r.handler = uint32(h)
+	r.data = data
+	s.arm(idx, d, period)
+	s.timers++
+	return SlabTimer(uint64(r.gen)<<32 | uint64(idx)), nil
+}
+
+// StopTimer 停止 timer, timer 已经到期(一次性的)、已经 Stop 或者 t 无效时返回 false
+func (s *SlabWheel) StopTimer(t SlabTimer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, ok := s.live(t)
+	if !ok {
+		return false
+	}
+	s.unlink(idx)
+	s.release(idx)
+	s.timers--
+	return true
+}
+
+// ResetTimer 修改还没有到期的 timer 的到期时间和周期, timer 已经到期(一次性的)、已经 Stop 或者 t 无效时返回 false
+func (s *SlabWheel) ResetTimer(t SlabTimer, d, period time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx, ok := s.live(t)
+	if !ok {
+		return false
+	}
+	s.unlink(idx)
+	s.arm(idx, d, period)
+	return true
+}
+
+// Timers 返回时间轮中 timer 的数量
+func (s *SlabWheel) Timers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timers
+}
+
+// Stop 停止 tick goroutine, 之后 AddTimer 返回 ErrWheelStopped, 已经加入的 timer 不再执行
+func (s *SlabWheel) Stop() {
+	s.once.Do(func() { close(s.quit) })
+	<-s.done
+}
+
+func (s *SlabWheel) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.onTick(now)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *SlabWheel) at(idx uint32) *slabTimer {
+	return &s.chunks[idx>>slabChunkBits][idx&(slabChunk-1)]
+}
+
+// live 检查 t 是不是还在时间轮中的 timer, 调用者持有 s.mu
+func (s *SlabWheel) live(t SlabTimer) (uint32, bool) {
+	idx, gen := uint32(t), uint32(t>>32)
+	if idx == 0 || int(idx>>slabChunkBits) >= len(s.chunks) {
+		return 0, false
+	}
+	r := s.at(idx)
+	return idx, r.gen == gen && r.list != slabNoList
+}
+
+func (s *SlabWheel) alloc() uint32 {
+	if s.free == 0 {
+		s.grow()
+	}
+	idx := s.free
+	s.free = s.at(idx).next
+	return idx
+}
+
+// grow 新建一个 chunk 并把其中的 timer 都放进空闲链表, 第一个 chunk 的下标 0 保留
+func (s *SlabWheel) grow() {
+	base := uint32(len(s.chunks)) << slabChunkBits
+	s.chunks = append(s.chunks, make([]slabTimer, slabChunk))
+	for i := slabChunk - 1; i >= 0; i-- {
+		idx := base + uint32(i)
+		if idx == 0 {
+			break
+		}
+		r := s.at(idx)
+		r.list = slabNoList
+		r.next = s.free
+		s.free = idx
+	}
+}
+
+// release 把 timer 放回空闲链表, generation 加 1
+func (s *SlabWheel) release(idx uint32) {
+	r := s.at(idx)
+	r.gen++
+	r.list = slabNoList
+	r.next = s.free
+	s.free = idx
+}
+
+// arm 计算到期的 tick 并把 timer 放到对应的链表, 调用者持有 s.mu
+func (s *SlabWheel) arm(idx uint32, d, period time.Duration) {
+	r := s.at(idx)
+	r.expires = s.jiffies + durationToTicks(d, s.tick)
+	r.period = durationToTicks(period, s.tick)
+	s.link(idx)
+}
+
+// listOf 返回第 level 层第 i 个槽在 s.lists 中的下标
+func (s *SlabWheel) listOf(level int, i uint64) uint64 {
+	if level == 0 {
+		return i
+	}
+	return 1<<s.rootBits + uint64(level-1)<<s.levelBits + i
+}
+
+// overflowList 返回 overflow 在 s.lists 中的下标
+func (s *SlabWheel) overflowList() uint64 {
+	return uint64(len(s.lists) - 1)
+}
+
+// link 按 expires 把 timer 放到对应层的槽里, 槽位和 Wheel.addTimerInternal 一样由 geometry.slotOf 计算
+func (s *SlabWheel) link(idx uint32) {
+	r := s.at(idx)
+	l := s.overflowList()
+	if level, i, overflow := s.slotOf(r.expires, s.jiffies); !overflow {
+		l = s.listOf(level, i)
+	}
+	list := &s.lists[l]
+	r.list = uint32(l)
+	r.next = 0
+	r.prev = list.tail
+	if list.tail != 0 {
+		s.at(list.tail).next = idx
+	} else {
+		list.head = idx
+	}
+	list.tail = idx
+}
+
+func (s *SlabWheel) unlink(idx uint32) {
+	r := s.at(idx)
+	list := &s.lists[r.list]
+	if r.prev != 0 {
+		s.at(r.prev).next = r.next
+	} else {
+		list.head = r.next
+	}
+	if r.next != 0 {
+		s.at(r.next).prev = r.prev
+	} else {
+		list.tail = r.prev
+	}
+}
+
+// take 取出整个链表, 返回第一个 timer 的下标
+func (s *SlabWheel) take(l uint64) uint32 {
+	head := s.lists[l].head
+	s.lists[l] = slabList{}
+	return head
+}
+
+// relink 把链表 l 中的 timer 按 expires 重新放置, cascade 时使用
+func (s *SlabWheel) relink(l uint64) {
+	for idx := s.take(l); idx != 0; {
+		next := s.at(idx).next
+		s.link(idx)
+		idx = next
+	}
+}
+
+// onTick 推进一个 tick, 在释放锁之后执行到期的 callback
+func (s *SlabWheel) onTick(now time.Time) {
+	s.mu.Lock()
+	index := s.jiffies & s.rootMask
+	s.cascade(s.jiffies, func(level int, i uint64) {
+		s.relink(s.listOf(level, i))
+	}, func() {
+		s.relink(s.overflowList())
+	})
+	s.jiffies++
+
+	fired := s.fired[:0]
+	for idx := s.take(index); idx != 0; {
+		r := s.at(idx)
+		next := r.next
+		fired = append(fired, slabFire{handler: r.handler, data: r.data})
+		if r.period > 0 {
+			r.expires += r.period
+			if r.expires < s.jiffies {
+				r.expires = s.jiffies
+			}
+			s.link(idx)
+		} else {
+			s.release(idx)
+			s.timers--
+		}
+		idx = next
+	}
+	s.fired = fired
+	handlers := s.handlers
+	s.mu.Unlock()
+
+	for _, f := range fired {
+		handlers[f.handler](now, f.data)
+	}
+}
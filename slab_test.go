@@ -0,0 +1,177 @@
+package timer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSlabTimerNoPointers 测试 slab 中保存的数据不含指针。
+// 功能点：slabTimer/slabList/slabFire 都不含指针，GC 不需要扫描 slab 数组。
+// 方法：用 reflect 检查每个字段的类型都是整数。
+func TestSlabTimerNoPointers(t *testing.T) {
+	for _, v := range []interface{}{slabTimer{}, slabList{}, slabFire{}} {
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			switch f.Type.Kind() {
+			case reflect.Uint32, reflect.Uint64:
+			default:
+				t.Fatalf("%s.%s is %s, expected integer", typ.Name(), f.Name, f.Type)
+			}
+		}
+	}
+}
+
+// TestSlabWheelCascade 测试 SlabWheel 各层的 timer 按时到期。
+// 功能点：在 tv1、tv2、tv3 和 overflow 中的 timer 经过 cascade 后在请求的 tick 执行，handler 收到 AddTimer 的 data；
+// WithGeometry 设置的层级和 Wheel 一样生效。
+// 方法：默认层级和 WithGeometry(4, 3, 3) 两种层级下，不启动 tick goroutine，手动调用 onTick 推进，记录每个 timer 执行时的 jiffies。
+func TestSlabWheelCascade(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		opts  []Option
+		ticks []uint64
+	}{
+		{name: "default", ticks: []uint64{3, 300, 20000, maxWheelIdx + 5}},
+		{name: "geometry", opts: []Option{WithGeometry(4, 3, 3)}, ticks: []uint64{3, 100, 700, 1<<10 + 5}},
+	} {
+		s := newSlabWheel(time.Millisecond, c.opts...)
+		firedAt := map[uint64]uint64{}
+		h := s.RegisterHandler(func(_ time.Time, data uint64) {
+			firedAt[data] = s.jiffies - 1
+		})
+		ticks := c.ticks
+		for _, n := range ticks {
+			if _, err := s.AddTimer(time.Duration(n)*time.Millisecond, 0, h, n); err != nil {
+				t.Fatalf("%s: AddTimer(%d) err = %v", c.name, n, err)
+			}
+		}
+		if n := s.Timers(); n != len(ticks) {
+			t.Fatalf("%s: Timers() = %d, expected %d", c.name, n, len(ticks))
+		}
+		if s.lists[s.overflowList()].head == 0 {
+			t.Fatalf("%s: timer of %d ticks is not in overflow", c.name, ticks[3])
+		}
+
+		for _, n := range ticks[:3] {
+			for s.jiffies <= n {
+				s.onTick(time.Now())
+			}
+		}
+		//跳过中间的 tick 直接到最高层 cascade 之前, 中间的槽都是空的
+		s.jiffies = s.maxIdx
+		for s.jiffies <= ticks[3] {
+			s.onTick(time.Now())
+		}
+		for _, n := range ticks {
+			if at, ok := firedAt[n]; !ok || at != n {
+				t.Fatalf("%s: timer of %d ticks fired at %d (fired = %v)", c.name, n, at, ok)
+			}
+		}
+		if n := s.Timers(); n != 0 {
+			t.Fatalf("%s: Timers() = %d, expected 0", c.name, n)
+		}
+	}
+}
+
+// TestSlabWheelStaleHandle 测试 SlabTimer 的 generation。
+// 功能点：Stop 或者到期之后旧的 SlabTimer Stop/ResetTimer 返回 false；复用同一个下标的新 timer 不受旧 handle 影响；
+// 没有注册的 handler 返回 ErrUnknownHandler。
+// 方法：Stop 一个 timer 后创建新 timer，检查下标相同、generation 不同，用旧 handle 操作后新 timer 仍然正常执行。
+func TestSlabWheelStaleHandle(t *testing.T) {
+	s := newSlabWheel(time.Millisecond)
+	fired := 0
+	h := s.RegisterHandler(func(time.Time, uint64) { fired++ })
+
+	old, _ := s.AddTimer(5*time.Millisecond, 0, h, 0)
+	if !s.StopTimer(old) {
+		t.Fatal("StopTimer() = false, expected true")
+	}
+	if s.StopTimer(old) {
+		t.Fatal("second StopTimer() = true, expected false")
+	}
+	cur, _ := s.AddTimer(5*time.Millisecond, 0, h, 0)
+	if uint32(cur) != uint32(old) || cur == old {
+		t.Fatalf("new timer %x, expected same index with new generation of %x", cur, old)
+	}
+	if s.StopTimer(old) || s.ResetTimer(old, time.Millisecond, 0) {
+		t.Fatal("stale handle stopped or reset the new timer")
+	}
+	if s.StopTimer(0) {
+		t.Fatal("StopTimer(0) = true, expected false")
+	}
+	if _, err := s.AddTimer(time.Millisecond, 0, h+1, 0); err != ErrUnknownHandler {
+		t.Fatalf("AddTimer() with unregistered handler err = %v, expected ErrUnknownHandler", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		s.onTick(time.Now())
+	}
+	if fired != 1 {
+		t.Fatalf("fired = %d, expected 1", fired)
+	}
+	if s.StopTimer(cur) {
+		t.Fatal("StopTimer() of fired timer = true, expected false")
+	}
+}
+
+// TestSlabWheelPeriodic 测试 SlabWheel 的周期 timer。
+// 功能点：period 大于 0 的 timer 每 period 执行一次；ResetTimer 修改周期；在 handler 中可以 StopTimer。
+// 方法：周期 5 个 tick 推进 51 个 tick(处理第 0..50 个槽)检查执行次数，ResetTimer 为 10 个 tick 后再推进，第 3 次执行时在 handler 中 Stop。
+func TestSlabWheelPeriodic(t *testing.T) {
+	s := newSlabWheel(time.Millisecond)
+	var tm SlabTimer
+	fired := 0
+	stopAt := -1
+	h := s.RegisterHandler(func(time.Time, uint64) {
+		fired++
+		if fired == stopAt {
+			s.StopTimer(tm)
+		}
+	})
+	tm, _ = s.AddTimer(5*time.Millisecond, 5*time.Millisecond, h, 0)
+	for i := 0; i <= 50; i++ {
+		s.onTick(time.Now())
+	}
+	if fired != 10 {
+		t.Fatalf("fired = %d after 51 ticks, expected 10", fired)
+	}
+
+	if !s.ResetTimer(tm, 10*time.Millisecond, 10*time.Millisecond) {
+		t.Fatal("ResetTimer() = false, expected true")
+	}
+	fired = 0
+	for i := 0; i <= 50; i++ {
+		s.onTick(time.Now())
+	}
+	if fired != 5 {
+		t.Fatalf("fired = %d after reset, expected 5", fired)
+	}
+
+	fired, stopAt = 0, 3
+	for i := 0; i < 100; i++ {
+		s.onTick(time.Now())
+	}
+	if fired != 3 || s.Timers() != 0 {
+		t.Fatalf("fired = %d, Timers() = %d, expected 3 and 0", fired, s.Timers())
+	}
+}
+
+// TestSlabWheelRun 测试 SlabWheel 的 tick goroutine。
+// 功能点：NewSlabWheel 启动后 timer 按时执行；Stop 之后 AddTimer 返回 ErrWheelStopped，goroutine 退出。
+// 方法：加入一个短 timer 等待执行，Stop 后再 AddTimer。
+func TestSlabWheelRun(t *testing.T) {
+	s := NewSlabWheel(time.Millisecond)
+	fired := make(chan struct{}, 1)
+	h := s.RegisterHandler(func(time.Time, uint64) { fired <- struct{}{} })
+	if _, err := s.AddTimer(5*time.Millisecond, 0, h, 0); err != nil {
+		t.Fatalf("AddTimer() err = %v", err)
+	}
+	waitStruct(t, fired, time.Second, "slab timer")
+
+	s.Stop()
+	if _, err := s.AddTimer(time.Millisecond, 0, h, 0); err != ErrWheelStopped {
+		t.Fatalf("AddTimer() after Stop err = %v, expected %v", err, ErrWheelStopped)
+	}
+}
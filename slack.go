@@ -0,0 +1,66 @@
+package timer
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// WithSlack 设置 timer 允许推迟触发的比例, 和 Linux 的 timer slack 一样: 比如 0.1 表示 1s 的 timer 最多可以晚 100ms 触发。
+// 加入时间轮时 expires 在允许的范围内推迟到低位 bit 最多的 jiffies, 到期时间相近的 timer 落到同一个槽里一起执行,
+// 对齐到高层边界的 timer 也只需要 cascade 一次, 从而减少 cascade 和有 timer 到期的 tick(见 Stats 的 Wakeups/Cascaded)。
+// 单个 timer 可以用 SetSlack 覆盖; precise timer 不推迟
+func WithSlack(ratio float64) Option {
+	if ratio < 0 {
+		panic("slack ratio must not be negative")
+	}
+	return func(w *Wheel) {
+		w.slack = ratio
+	}
+}
+
+// applySlack 在 t 允许推迟的范围内把 t.expires 推迟到低位 bit 最多的 jiffies, 调用者持有 w.Lock。
+// 只在加入时间轮时调用, cascade 时不再推迟
+func (w *Wheel) applySlack(t *timer) {
+	t.slacked = 0
+	if t.precise || t.expires <= w.jiffies {
+		return
+	}
+	var s uint64
+	if t.slackSet {
+		s = uint64(t.slack / w.tick)
+	} else if w.slack > 0 {
+		s = uint64(w.slack * float64(t.expires-w.jiffies))
+	}
+	if s == 0 {
+		return
+	}
+	//和 Linux 的 apply_slack 一样: limit 和 expires 最高的不同 bit 以下全部清零, 结果在 [expires, limit] 之间
+	limit := t.expires + s
+	bit := bits.Len64(t.expires^limit) - 1
+	rounded := limit &^ (1<<uint(bit) - 1)
+	if rounded == t.expires {
+		return
+	}
+	t.slacked = rounded - t.expires
+	t.expires = rounded
+	atomic.AddUint64(&w.slacked, 1)
+}
+
+// setSlack 修改 t 的 slack, t 在时间轮中时按新的 slack 重新放置; t 已经不是 gen 这一代时什么都不做
+func (w *Wheel) setSlack(t *timer, gen uint32, d time.Duration) {
+	w.Lock()
+	defer w.Unlock()
+	w.drainLocked()
+	if !t.live(gen) {
+		return
+	}
+	t.slack, t.slackSet = d, true
+	if t.list == nil || t.list == &w.precise || t.list == &w.parked {
+		return
+	}
+	w.unlink(t)
+	t.expires -= t.slacked
+	w.applySlack(t)
+	w.addTimerInternal(t)
+}
@@ -0,0 +1,95 @@
+package timer
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestSlackRounding 测试单个 timer 的 slack。
+// 功能点：SetSlack 之后时间轮中的 timer 马上按 slack 推迟到低位 bit 最多的 jiffies，结果不早于原来的 expires，也不晚于 expires+slack；
+// SetSlack(0) 恢复原来的 expires。
+// 方法：从 jiffies 0 开始的 ManualWheel 中创建 1000ms 的 timer，SetSlack(100ms) 后检查 expires 为 1024。
+func TestSlackRounding(t *testing.T) {
+	m := NewManualWheel(time.Millisecond, time.Unix(0, 0))
+	defer m.Stop()
+	tm := m.NewWheelTimerFunc(time.Second, func(time.Time, ...interface{}) {})
+	if tm.expires != 1000 {
+		t.Fatalf("expires = %d, expected 1000", tm.expires)
+	}
+	tm.SetSlack(100 * time.Millisecond)
+	if tm.expires != 1024 {
+		t.Fatalf("expires with 100ms slack = %d, expected 1024", tm.expires)
+	}
+	tm.SetSlack(0)
+	if tm.expires != 1000 {
+		t.Fatalf("expires with 0 slack = %d, expected 1000", tm.expires)
+	}
+}
+
+// TestWheelSlackReducesWakeups 测试时间轮的 slack 对唤醒次数和 cascade 的影响。
+// 功能点：WithSlack(0.1) 的时间轮中 timer 不早于 deadline、不晚于 deadline 的 110%(加上 tick 的取整)触发，
+// 有 timer 到期的 tick 数量(Wakeups)远少于、cascade 的 timer 数量(Cascaded)明显少于没有 slack 的时间轮。
+// 方法：在有和没有 slack 的模拟时间轮中加入同样的随机 timer，RunUntilIdle 后比较 Stats。
+func TestWheelSlackReducesWakeups(t *testing.T) {
+	run := func(opts ...Option) Stats {
+		start := time.Unix(0, 0)
+		sim := NewSimWheel(time.Millisecond, start, opts...)
+		defer sim.Stop()
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 5000; i++ {
+			d := time.Duration(rnd.Int63n(int64(10*time.Minute))) + time.Second
+			due := start.Add(d)
+			sim.AfterFunc(d, func() {
+				now := sim.Now()
+				if now.Before(due) || now.Sub(due) > d/10+2*time.Millisecond {
+					t.Fatalf("%v timer fired %v late, expected within [0, %v]", d, now.Sub(due), d/10)
+				}
+			})
+		}
+		sim.RunUntilIdle()
+		return sim.Stats()
+	}
+	exact := run()
+	slack := run(WithSlack(0.1))
+	t.Logf("without slack: %+v", exact)
+	t.Logf("with slack:    %+v", slack)
+	if slack.Slacked == 0 {
+		t.Fatal("Slacked = 0, expected timers deferred by slack")
+	}
+	if slack.Wakeups*4 > exact.Wakeups {
+		t.Fatalf("Wakeups with slack = %d, expected far fewer than %d", slack.Wakeups, exact.Wakeups)
+	}
+	if slack.Cascaded*4 > exact.Cascaded*3 {
+		t.Fatalf("Cascaded with slack = %d, expected fewer than 3/4 of %d", slack.Cascaded, exact.Cascaded)
+	}
+}
+
+// TestSlackPeriodicNoDrift 测试周期 timer 的 slack 不会累积。
+// 功能点：FixedRate 的周期 timer 每次从推迟之前的 expires 计算下一次，slack 只让单次执行推迟，执行次数不会变少。
+// 方法：模拟时间轮中 100ms 周期、SetSlack(30ms) 的 FixedRate timer 运行 10s，检查执行次数和每次的推迟。
+func TestSlackPeriodicNoDrift(t *testing.T) {
+	start := time.Unix(0, 0)
+	sim := NewSimWheel(time.Millisecond, start)
+	defer sim.Stop()
+
+	fires := 0
+	tm := sim.NewWheelTimerFunc(100*time.Millisecond, func(now time.Time, _ ...interface{}) {
+		fires++
+		due := start.Add(time.Duration(fires) * 100 * time.Millisecond)
+		if late := now.Sub(due); late < 0 || late > 32*time.Millisecond {
+			t.Fatalf("fire %d is %v late, expected within [0, 32ms]", fires, late)
+		}
+	})
+	tm.SetPeriodMode(FixedRate)
+	tm.ResetTimer(100*time.Millisecond, 100*time.Millisecond)
+	tm.SetSlack(30 * time.Millisecond)
+	sim.RunUntil(start.Add(10*time.Second + 50*time.Millisecond))
+	tm.Stop()
+	if fires != 100 {
+		t.Fatalf("fires = %d, expected 100", fires)
+	}
+	if s := sim.Stats(); s.Slacked == 0 {
+		t.Fatal("Slacked = 0, expected the ticker deferred by slack")
+	}
+}
@@ -0,0 +1,50 @@
+package timer
+
+import "sync/atomic"
+
+// Stats 是时间轮的运行统计
+type Stats struct {
+	Timers   int    //时间轮中还没有执行的 timer 数量
+	Running  int32  //正在执行 timer callback 的 goroutine 数量
+	Dropped  uint64 //channel ticker 因为消费者跟不上而丢弃的 tick 数量
+	Stuck    uint64 //watchdog 发现的执行时间超过预算的 callback 数量
+	Rejected uint64 //因为 WithMaxTimers 或者 Group 配额被拒绝的 timer 数量
+	Evicted  uint64 //因为 WithMaxTimers 或者 Group 配额被淘汰的 timer 数量
+	Wakeups  uint64 //有 timer 到期的 tick 数量, 即执行 callback 的次数(不是 timer 的数量)
+	Cascaded uint64 //cascade 时重新放置的 timer 数量
+	Slacked  uint64 //因为 slack 推迟了到期时间的 timer 数量, 见 WithSlack
+}
+
+func (s *Stats) add(o Stats) {
+	s.Timers += o.Timers
+	s.Running += o.Running
+	s.Dropped += o.Dropped
+	s.Stuck += o.Stuck
+	s.Rejected += o.Rejected
+	s.Evicted += o.Evicted
+	s.Wakeups += o.Wakeups
+	s.Cascaded += o.Cascaded
+	s.Slacked += o.Slacked
+}
+
+func (w *Wheel) Stats() Stats {
+	return Stats{
+		Timers:   w.Timers(),
+		Running:  atomic.LoadInt32(&w.taskRuning),
+		Dropped:  atomic.LoadUint64(&w.dropped),
+		Stuck:    atomic.LoadUint64(&w.stuck),
+		Rejected: atomic.LoadUint64(&w.rejected),
+		Evicted:  atomic.LoadUint64(&w.evicted),
+		Wakeups:  atomic.LoadUint64(&w.wakeups),
+		Cascaded: atomic.LoadUint64(&w.cascaded),
+		Slacked:  atomic.LoadUint64(&w.slacked),
+	}
+}
+
+func (ws *wheel_shard) Stats() Stats {
+	var s Stats
+	for i := 0; i < len(ws.wheels); i++ {
+		s.add(ws.wheels[i].Stats())
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package timer
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// rearm 把执行完的周期 timer 重新加入时间轮; StopWait 已经要求停止或者所属的 group 已经 Stop 就不再加入, 返回 false。
+// 检查和加入在同一个 w.Lock 里, 避免 StopWait/Group.Stop 返回之后 timer 又被加回去
+func (w *Wheel) rearm(t *timer) bool {
+	w.Lock()
+	if t.stopReq || (t.group != nil && t.group.stopped) {
+		t.stopReq = false
+		t.state = Stoped
+		w.Unlock()
+		return false
+	}
+	err := w.addLocked(t)
+	w.Unlock()
+	if err != nil {
+		w.log.Errorf("add period timer:%+v fail, err:%v", t, err)
+	}
+	return true
+}
+
+// finish callback 执行完(周期 timer 已经重新加入或者停止), 唤醒等待中的 StopWait
+func (w *Wheel) finish(t *timer) {
+	atomic.StoreInt32(&t.busy, 0)
+	//先清 busy 再读 waiters, 和 stopWait 的先加 waiters 再读 busy 配对, 保证不会漏掉唤醒
+	if atomic.LoadInt32(&w.waiters) == 0 {
+		return
+	}
+	w.Lock()
+	if w.idle != nil {
+		close(w.idle)
+		w.idle = nil
+	}
+	w.Unlock()
+}
+
+// stopWait 返回 cancelled 表示 timer 还没到期就被取消了
+func (w *Wheel) stopWait(ctx context.Context, t *timer, gen uint32) (cancelled bool, err error) {
+	w.Lock()
+	if !t.live(gen) {
+		w.Unlock()
+		return false, ErrTimerReleased
+	}
+	w.drainLocked()
+	pending := t.list != nil
+	if err := w.delLocked(t); err != ErrTimerFired {
+		//还没到期直接取消了, 或者已经 Stop
+		w.Unlock()
+		return pending, err
+	}
+	if atomic.LoadInt32(&t.busy) == 0 {
+		//已经执行完
+		w.Unlock()
+		return false, nil
+	}
+	t.stopReq = true
+	atomic.AddInt32(&w.waiters, 1)
+	defer atomic.AddInt32(&w.waiters, -1)
+	for {
+		if w.idle == nil {
+			w.idle = make(chan struct{})
+		}
+		idle := w.idle
+		w.Unlock()
+		if atomic.LoadInt32(&t.busy) == 0 || !t.live(gen) {
+			return false, nil
+		}
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			//timer 执行完后仍然不会重新加入时间轮
+			return false, ctx.Err()
+		}
+		w.Lock()
+	}
+}
+
+// StopWait 停止 timer: 还没到期就直接取消; 已经到期就等正在执行(或者即将执行)的 callback 执行完,
+// 并且周期 timer 不会再被重新加入时间轮, 返回 nil 之后 callback 不会再执行。
+// ctx 结束时返回 ctx.Err()。不能在 timer 自己的 callback 里调用, 会一直等到 ctx 结束
+func (t *timer) StopWait(ctx context.Context) error {
+	return t.stopWait(ctx, atomic.LoadUint32(&t.gen))
+}
+
+func (t *timer) stopWait(ctx context.Context, gen uint32) error {
+	w := t.w
+	cancelled, err := w.stopWait(ctx, t, gen)
+	if cancelled && w.autoRelease && t.period == 0 {
+		//执行过的由 wheel 负责 Release, 这里只处理被取消的, 和 TryStop 一样
+		w.releaseTimer(t, gen)
+	}
+	return err
+}
+
+// StopWait 见 WheelTimer.StopWait, 返回之后不会再往 C 发送
+func (t *Timer) StopWait(ctx context.Context) error {
+	if !t.r.live(t.gen) {
+		return ErrTimerReleased
+	}
+	return t.r.stopWait(ctx, t.gen)
+}
+
+// StopWait 见 WheelTimer.StopWait, 返回之后不会再往 C 发送
+func (t *Ticker) StopWait(ctx context.Context) error {
+	if !t.r.live(t.gen) {
+		return ErrTimerReleased
+	}
+	return t.r.stopWait(ctx, t.gen)
+}
+
+// StopWait 见 WheelTimer.StopWait
+func (t *EventTicker) StopWait(ctx context.Context) error {
+	if !t.r.live(t.gen) {
+		return ErrTimerReleased
+	}
+	return t.r.stopWait(ctx, t.gen)
+}
@@ -0,0 +1,113 @@
+package timer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStopWaitWaitsForRunningCallback 测试 StopWait 等待正在执行的 callback。
+// 功能点：callback 执行中调用 StopWait，要等 callback 返回后 StopWait 才返回；周期 timer 之后不再触发。
+// 方法：周期 timer 的 callback 阻塞在 channel 上，在另一个 goroutine 调用 StopWait，放行 callback 后检查执行次数不再增加。
+func TestStopWaitWaitsForRunningCallback(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	var fires int32
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	tm := w.NewWheelTimerFunc(testTick, func(time.Time, ...interface{}) {
+		if atomic.AddInt32(&fires, 1) == 1 {
+			entered <- struct{}{}
+			<-release
+		}
+	})
+	tm.ResetTimer(testTick, testTick)
+	waitStruct(t, entered, 200*time.Millisecond, "first callback")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tm.StopWait(context.Background())
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("StopWait() returned %v while callback is running", err)
+	case <-time.After(5 * testTick):
+	}
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("StopWait() err = %v, expected nil", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("StopWait() did not return after callback finished")
+	}
+
+	got := atomic.LoadInt32(&fires)
+	time.Sleep(5 * testTick)
+	if now := atomic.LoadInt32(&fires); now != got {
+		t.Fatalf("periodic timer fired %d times after StopWait, expected 0", now-got)
+	}
+	if n := w.Timers(); n != 0 {
+		t.Fatalf("Timers() = %d, expected 0", n)
+	}
+	if !tm.Stop() {
+		t.Fatal("Stop() after StopWait = false, expected stopped timer")
+	}
+}
+
+// TestStopWaitCancelsPendingTimer 测试 StopWait 取消还没到期的 timer。
+// 功能点：Timer/Ticker 还在时间轮中时 StopWait 直接取消并返回 nil；Release 之后返回 ErrTimerReleased。
+// 方法：创建较长的 Timer 和 Ticker，调用 StopWait 后检查 Timers()，Release 后再次调用。
+func TestStopWaitCancelsPendingTimer(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	tm := w.NewTimer(time.Second)
+	tk := w.NewTicker(time.Second)
+
+	if err := tm.StopWait(context.Background()); err != nil {
+		t.Fatalf("Timer.StopWait() err = %v, expected nil", err)
+	}
+	if err := tk.StopWait(context.Background()); err != nil {
+		t.Fatalf("Ticker.StopWait() err = %v, expected nil", err)
+	}
+	if n := w.Timers(); n != 0 {
+		t.Fatalf("Timers() = %d, expected 0", n)
+	}
+
+	tm.Release()
+	tk.Release()
+	if err := tm.StopWait(context.Background()); err != ErrTimerReleased {
+		t.Fatalf("Timer.StopWait() after Release err = %v, expected ErrTimerReleased", err)
+	}
+	if err := tk.StopWait(context.Background()); err != ErrTimerReleased {
+		t.Fatalf("Ticker.StopWait() after Release err = %v, expected ErrTimerReleased", err)
+	}
+}
+
+// TestStopWaitContextTimeout 测试 StopWait 的 ctx 超时。
+// 功能点：callback 一直不返回时 StopWait 在 ctx 结束时返回 ctx.Err()，callback 返回后周期 timer 也不会再加入时间轮。
+// 方法：周期 callback 阻塞，用带超时的 ctx 调用 StopWait，放行后检查 Timers()。
+func TestStopWaitContextTimeout(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var once int32
+	tm := w.NewWheelTimerFunc(testTick, func(time.Time, ...interface{}) {
+		if atomic.CompareAndSwapInt32(&once, 0, 1) {
+			entered <- struct{}{}
+			<-release
+		}
+	})
+	tm.ResetTimer(testTick, testTick)
+	waitStruct(t, entered, 200*time.Millisecond, "first callback")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*testTick)
+	defer cancel()
+	if err := tm.StopWait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("StopWait() err = %v, expected context.DeadlineExceeded", err)
+	}
+	close(release)
+	requireEventually(t, 200*time.Millisecond, func() bool {
+		return tm.StopWait(context.Background()) == nil && w.Timers() == 0
+	}, "periodic timer was re-armed after StopWait timeout")
+}
@@ -0,0 +1,148 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// adaptDelay 自适应模式下负载持续低多久之后才把 tick 变粗, 避免 timer 数量在 lowWater 附近来回切换
+const adaptDelay = time.Second
+
+// WithAdaptiveTick 开启自适应 tick: timer 数量持续 1s 不超过 lowWater 时 tick 变为 max, 降低空闲时 tick 的开销;
+// timer 数量超过 lowWater 时马上切回创建时(或者 SetTick 设置)的 tick。tick 变粗期间 timer 的精度也是 max。
+// ManualWheel/SimWheel/MultiWheel 中不生效
+func WithAdaptiveTick(max time.Duration, lowWater int) Option {
+	return func(w *Wheel) {
+		w.maxTick = max
+		w.lowWater = lowWater
+	}
+}
+
+// Resolution 返回当前的 tick
+func (w *Wheel) Resolution() time.Duration {
+	tick, _ := w.curTick()
+	return tick
+}
+
+// SetTick 修改时间轮的 tick: 时间轮中所有 timer 按请求的到期时间(deadline)重新计算 expires 和周期,
+// 绝对的到期时间不变, 之后每个 tick 的间隔是 d。之后 rearm 的周期 timer 也按新的 tick 计算。
+// 自适应模式下 d 作为负载高时的 tick, d 不小于 max 时不再自适应。ManualWheel/SimWheel/MultiWheel 返回 ErrTickFixed
+func (w *Wheel) SetTick(d time.Duration) error {
+	if d <= 0 {
+		panic("tick must be greater than 0")
+	}
+	if w.single || w.multi != nil {
+		return ErrTickFixed
+	}
+	w.Lock()
+	w.baseTick = d
+	w.setTickLocked(d)
+	w.Unlock()
+	w.notifyTick()
+	return nil
+}
+
+// curTick 返回当前的 tick 和它的序号。setTickLocked 先写 tick 再写序号, 这里反过来读:
+// 读到新的序号时 tick 一定也是新的; 读到旧的序号和新的 tick 时, addLocked 会按 deadline 重新计算
+func (w *Wheel) curTick() (time.Duration, uint32) {
+	seq := atomic.LoadUint32(&w.tickSeq)
+	return time.Duration(atomic.LoadInt64((*int64)(&w.tick))), seq
+}
+
+// notifyTick 通知 run goroutine 检查 tick
+func (w *Wheel) notifyTick() {
+	select {
+	case w.tickChanged <- struct{}{}:
+	default:
+	}
+}
+
+// setTickLocked 修改 tick 并把时间轮中的 timer 按新的 tick 重新放置, 调用者持有 w.Lock。
+// precise 队列中的 timer 由 runtime timer 派发, 不受 tick 影响
+func (w *Wheel) setTickLocked(d time.Duration) {
+	w.drainLocked()
+	if d == w.tick {
+		return
+	}
+	w.rebaseClock(w.clockAt(w.jiffies))
+	atomic.StoreInt64((*int64)(&w.tick), int64(d))
+	atomic.AddUint32(&w.tickSeq, 1)
+
+	//整个槽直接接到 pending 后面, 只需要查 bitmap 找非空的槽
+	var pending timerList
+	for level, tv := range w.tv {
+		n := uint64(len(tv))
+		for i, ok := w.occ[level].next(0, n); ok; i, ok = w.occ[level].next(i, n) {
+			slot := w.takeSlot(level, i)
+			pending.PushBackList(&slot)
+		}
+	}
+	pending.PushBackList(&w.overflow)
+	w.overflowTimers = 0
+	w.overflowBounds = bounds{}
+
+	now := w.now().UnixNano()
+	for !pending.Empty() {
+		t := pending.Front()
+		pending.Remove(t)
+		w.place(t, now)
+	}
+}
+
+// place 按 t.deadline 重新计算 expires 后把 t 放进时间轮, 调用者持有 w.Lock
+func (w *Wheel) place(t *timer, now int64) {
+	w.retick(t, now)
+	if t.precise && t.deadline-now < int64(w.tick) {
+		w.addPrecise(t)
+	} else {
+		w.applySlack(t)
+		w.addTimerInternal(t)
+	}
+}
+
+// retick 按 t.deadline 和当前的 tick 重新计算 t 的 expires 和 period, 调用者持有 w.Lock
+func (w *Wheel) retick(t *timer, now int64) {
+	if t.period > 0 {
+		t.period = durationToTicks(t.interval, w.tick)
+		if t.period == 0 {
+			t.period = 1
+		}
+	}
+	if t.precise {
+		w.setPreciseExpires(t, now)
+		return
+	}
+	t.expires = w.jiffies + durationToTicks(time.Duration(t.deadline-now), w.tick)
+	t.tickSeq = w.tickSeq
+}
+
+// adapt 自适应模式下按 timer 数量切换 tick, 返回 tick 是否改变, 只在 run goroutine 中调用
+func (w *Wheel) adapt(now time.Time) bool {
+	w.Lock()
+	defer w.Unlock()
+	w.drainLocked()
+	if w.baseTick >= w.maxTick {
+		//SetTick 设置的 tick 已经不比 maxTick 细, 不需要自适应
+		return false
+	}
+	if w.timers > w.lowWater {
+		w.lightSince = time.Time{}
+		if w.tick == w.baseTick {
+			return false
+		}
+		w.setTickLocked(w.baseTick)
+		return true
+	}
+	if w.tick == w.maxTick {
+		return false
+	}
+	if w.lightSince.IsZero() {
+		w.lightSince = now
+		return false
+	}
+	if now.Sub(w.lightSince) < adaptDelay {
+		return false
+	}
+	w.setTickLocked(w.maxTick)
+	return true
+}
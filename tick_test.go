@@ -0,0 +1,96 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSetTickRecomputeExpires 测试 SetTick 重新计算时间轮中的 timer。
+// 功能点：SetTick 之后 timer 的 expires 和 period 按新的 tick 计算，绝对的到期时间不变；Resolution 返回新的 tick。
+// 方法：10ms tick 的时间轮中加入 1h 的 timer 和 30s 的周期 timer，SetTick 到 1min 和 1s，检查剩余的 tick 数和周期。
+func TestSetTickRecomputeExpires(t *testing.T) {
+	w := newTestWheel(t, 10*time.Millisecond)
+	f := func(time.Time, ...interface{}) {}
+	once := w.NewWheelTimerFunc(time.Hour, f)
+	periodic := w.NewWheelTimerFunc(30*time.Second, f)
+	periodic.ResetTimer(30*time.Second, 30*time.Second)
+
+	check := func(tick time.Duration) {
+		t.Helper()
+		if err := w.SetTick(tick); err != nil {
+			t.Fatalf("SetTick(%v) err = %v, expected nil", tick, err)
+		}
+		if got := w.Resolution(); got != tick {
+			t.Fatalf("Resolution() = %v, expected %v", got, tick)
+		}
+		w.Lock()
+		defer w.Unlock()
+		if left := once.expires - w.jiffies; left != uint64(time.Hour/tick) {
+			t.Fatalf("tick %v: 1h timer expires in %d ticks, expected %d", tick, left, time.Hour/tick)
+		}
+		if want := durationToTicks(30*time.Second, tick); periodic.period != want {
+			t.Fatalf("tick %v: period = %d ticks, expected %d", tick, periodic.period, want)
+		}
+	}
+	check(time.Minute)
+	check(time.Second)
+	checkOccupancy(t, w)
+	if n := w.RealTimers(); n != 2 {
+		t.Fatalf("RealTimers() = %d, expected 2", n)
+	}
+}
+
+// TestSetTickFire 测试 SetTick 之后 timer 按新的 tick 触发。
+// 功能点：tick 变细之后，已经在时间轮中的 timer 按原来的 deadline 以新的精度触发，ticker 按新的 tick 运行。
+// 方法：200ms tick 的时间轮中加入 250ms 的 timer，马上 SetTick(time.Millisecond)，检查触发时间远早于旧 tick 的 400ms。
+func TestSetTickFire(t *testing.T) {
+	w := newTestWheel(t, 200*time.Millisecond)
+	start := time.Now()
+	tm := w.NewTimer(250 * time.Millisecond)
+	if err := w.SetTick(time.Millisecond); err != nil {
+		t.Fatalf("SetTick() err = %v, expected nil", err)
+	}
+	fired := waitTime(t, tm.C, time.Second, "timer after SetTick")
+	if d := fired.Sub(start); d < 250*time.Millisecond || d > 380*time.Millisecond {
+		t.Fatalf("timer fired after %v, expected about 250ms", d)
+	}
+}
+
+// TestAdaptiveTick 测试自适应 tick。
+// 功能点：timer 数量持续不超过 lowWater 时 tick 变为 max；timer 数量超过 lowWater 后马上切回原来的 tick，timer 按原来的精度触发。
+// 方法：1ms tick、WithAdaptiveTick(100ms, 2) 的空时间轮等待 tick 变粗，再加入 5 个 50ms 的 timer，检查 tick 和触发时间。
+func TestAdaptiveTick(t *testing.T) {
+	w := newTestWheel(t, time.Millisecond, WithAdaptiveTick(100*time.Millisecond, 2))
+	requireEventually(t, 3*time.Second, func() bool {
+		return w.Resolution() == 100*time.Millisecond
+	}, "tick not coarsened while idle")
+
+	start := time.Now()
+	var timers []*Timer
+	for i := 0; i < 5; i++ {
+		timers = append(timers, w.NewTimer(50*time.Millisecond))
+	}
+	requireEventually(t, 50*time.Millisecond, func() bool {
+		return w.Resolution() == time.Millisecond
+	}, "tick not restored when loaded")
+	for _, tm := range timers {
+		fired := waitTime(t, tm.C, time.Second, "timer after tick restored")
+		if d := fired.Sub(start); d > 120*time.Millisecond {
+			t.Fatalf("timer fired after %v, expected about 50ms", d)
+		}
+	}
+}
+
+// TestManualWheelSetTick 测试 ManualWheel 的 tick 不能修改。
+// 功能点：SimWheel 的 SetTick 返回 ErrTickFixed，tick 不变。
+// 方法：调用 SetTick 检查返回值和 Resolution。
+func TestManualWheelSetTick(t *testing.T) {
+	sim := NewSimWheel(time.Millisecond, time.Unix(0, 0))
+	defer sim.Stop()
+	if err := sim.SetTick(time.Second); err != ErrTickFixed {
+		t.Fatalf("SetTick() err = %v, expected %v", err, ErrTickFixed)
+	}
+	if got := sim.Resolution(); got != time.Millisecond {
+		t.Fatalf("Resolution() = %v, expected 1ms", got)
+	}
+}
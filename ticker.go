@@ -1,38 +1,111 @@
 package timer
 
 import (
+	"sync/atomic"
 	"time"
 )
 
 type Ticker struct {
-	C <-chan time.Time
-	r *timer
+	C   <-chan time.Time
+	c   chan time.Time //和 C 是同一个 channel, Release 之后放回 pool
+	r   *timer
+	s   *tickSender //channel ticker 的发送策略, TickFunc 创建的 ticker 为 nil
+	gen uint32      //创建时 r 的 generation, r 被 Release 后所有操作都不再生效
+
+	sender tickSender //s 指向这里, 和外壳一起复用
 }
 
-func NewTicker(d time.Duration) *Ticker {
+func NewTicker(d time.Duration, opts ...TickerOption) *Ticker {
 	//return defaultWheel.NewTicker(d)
-	return defaultWheelShard.NewTicker(d)
+	return defaultWheelShard.NewTicker(d, opts...)
 }
 
-func TickFunc(d time.Duration, f func()) *Ticker {
+func TickFunc(d time.Duration, f func(), opts ...TickerOption) *Ticker {
 	//return defaultWheel.TickFunc(d, f)
-	return defaultWheelShard.TickFunc(d, f)
+	return defaultWheelShard.TickFunc(d, f, opts...)
 }
 
-func Tick(d time.Duration) <-chan time.Time {
+func Tick(d time.Duration, opts ...TickerOption) <-chan time.Time {
 	//return defaultWheel.Tick(d)
-	return defaultWheelShard.Tick(d)
+	return defaultWheelShard.Tick(d, opts...)
+}
+
+type TickerOption func(*tickerConfig)
+
+type tickerConfig struct {
+	mode    PeriodMode
+	buffer  int
+	policy  SendPolicy
+	timeout time.Duration
+}
+
+// defaultTickerConfig 是没有 TickerOption 时的配置, 只读, 避免每次创建 ticker 都分配 tickerConfig
+var defaultTickerConfig = tickerConfig{buffer: 1}
+
+func newTickerConfig(opts []TickerOption) *tickerConfig {
+	if len(opts) == 0 {
+		return &defaultTickerConfig
+	}
+	c := &tickerConfig{buffer: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// apply 在 timer 加入时间轮之前调用
+func (c *tickerConfig) apply(t *timer) {
+	atomic.StoreInt32(&t.mode, int32(c.mode))
+}
+
+// WithPeriodMode 设置 ticker 的周期调度方式, 默认是 FixedDelay
+func WithPeriodMode(mode PeriodMode) TickerOption {
+	return func(c *tickerConfig) {
+		c.mode = mode
+	}
 }
 
 func (t *Ticker) Stop() bool {
 	//t.r.w.delTimer(t.r)
-	return t.r.Stop()
+	return t.r.stop(t.gen) == nil
 }
 
+// Release 之后旧的 Ticker 上的操作都不再生效; 开启 WithWrapperPool 时 t 和 t.C 会被复用, 不能再使用
 func (t *Ticker) Release() {
-	t.r.Release()
+	if t.r.releaseWrapped(t.gen) {
+		t.recycle()
+	}
 }
 
 func (t *Ticker) Reset(d time.Duration) {
-	t.r.w.resetTimer(t.r, d, d)
+	t.r.w.resetTimer(t.r, t.gen, d, d)
+}
+
+// Handle 返回带 generation 的 handle, 见 WheelTimer.Handle
+func (t *Ticker) Handle() Handle {
+	return Handle{t: t.r, gen: t.gen}
+}
+
+// SetPeriodMode 修改 ticker 的周期调度方式, 见 WithPeriodMode, 下一次重新调度时生效; Release 之后不再生效
+func (t *Ticker) SetPeriodMode(mode PeriodMode) {
+	t.r.w.setPeriodMode(t.r, t.gen, mode)
+}
+
+// SetSlack 见 WheelTimer.SetSlack
+func (t *Ticker) SetSlack(d time.Duration) {
+	t.r.w.setSlack(t.r, t.gen, d)
+}
+
+// Missed 见 WheelTimer.Missed, 只在 TickFunc 的回调中调用有意义;
+// f 在新的 goroutine 中执行, 需要本次执行准确的值时用 TickInfoFunc, 从 FireInfo.Missed 读
+func (t *Ticker) Missed() uint64 {
+	return t.r.Missed()
+}
+
+// Dropped 返回因为 channel 满而丢弃的 tick 数量, TickFunc 创建的 ticker 总是 0
+func (t *Ticker) Dropped() uint64 {
+	if t.s == nil {
+		return 0
+	}
+	return t.s.Dropped()
 }
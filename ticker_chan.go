@@ -0,0 +1,136 @@
+package timer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SendPolicy 表示 channel ticker 的消费者跟不上时如何处理新的 tick
+type SendPolicy int
+
+const (
+	// DropNewest 默认策略: channel 满时丢弃新的 tick 并计数, 和 time.Ticker 一样不阻塞时间轮。
+	DropNewest SendPolicy = iota
+	// BlockTimeout channel 满时最多阻塞 timeout, 超时后丢弃并计数。
+	// 阻塞发生在执行 timer 的 goroutine 中, 会推迟同一批到期 timer 的执行。
+	BlockTimeout
+	// CoalesceLatest channel 满时丢弃最旧的 tick, 放入最新的 tick, 被替换的 tick 计入丢弃数量。
+	CoalesceLatest
+)
+
+// WithBuffer 设置 ticker channel 的缓冲大小, 默认是 1
+func WithBuffer(n int) TickerOption {
+	if n < 0 {
+		panic("ticker buffer must not be negative")
+	}
+	return func(c *tickerConfig) {
+		c.buffer = n
+	}
+}
+
+// WithBlockTimeout channel 满时最多阻塞 timeout 再丢弃, 见 BlockTimeout
+func WithBlockTimeout(timeout time.Duration) TickerOption {
+	return func(c *tickerConfig) {
+		c.policy = BlockTimeout
+		c.timeout = timeout
+	}
+}
+
+// WithCoalesce channel 满时用最新的 tick 替换最旧的 tick, 见 CoalesceLatest
+func WithCoalesce() TickerOption {
+	return func(c *tickerConfig) {
+		c.policy = CoalesceLatest
+	}
+}
+
+// WithDrop channel 满时丢弃新的 tick 并计数, 这是默认策略
+func WithDrop() TickerOption {
+	return func(c *tickerConfig) {
+		c.policy = DropNewest
+	}
+}
+
+// tickSender 保存 channel ticker 的发送策略和丢弃计数, c 和 ec 只有一个不为 nil
+type tickSender struct {
+	c       chan time.Time
+	ec      chan TickEvent
+	policy  SendPolicy
+	timeout time.Duration
+	dropped uint64
+	w       *Wheel
+}
+
+func (c *tickerConfig) newSender(w *Wheel) *tickSender {
+	return &tickSender{policy: c.policy, timeout: c.timeout, w: w}
+}
+
+func (s *tickSender) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *tickSender) drop() {
+	atomic.AddUint64(&s.dropped, 1)
+	atomic.AddUint64(&s.w.dropped, 1)
+}
+
+func sendTick(t time.Time, arg ...interface{}) {
+	s := arg[0].(*tickSender)
+	select {
+	case s.c <- t:
+		return
+	default:
+	}
+
+	switch s.policy {
+	case BlockTimeout:
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		select {
+		case s.c <- t:
+			return
+		case <-timer.C:
+		}
+	case CoalesceLatest:
+		//消费者可能同时在读, 取不到旧值也没关系, 再尝试一次发送
+		select {
+		case <-s.c:
+		default:
+		}
+		select {
+		case s.c <- t:
+		default:
+		}
+	}
+	s.drop()
+}
+
+func sendEvent(fi FireInfo, arg ...interface{}) {
+	s := arg[0].(*tickSender)
+	ev := TickEvent{fi}
+	select {
+	case s.ec <- ev:
+		return
+	default:
+	}
+
+	switch s.policy {
+	case BlockTimeout:
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		select {
+		case s.ec <- ev:
+			return
+		case <-timer.C:
+		}
+	case CoalesceLatest:
+		select {
+		case <-s.ec:
+		default:
+		}
+		select {
+		case s.ec <- ev:
+		default:
+		}
+	}
+	s.drop()
+}
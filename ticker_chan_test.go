@@ -0,0 +1,69 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTickerDropCountsLostTicks 测试默认 drop 策略的丢弃计数。
+// 功能点：消费者不读 channel 时，超出缓冲的 tick 应被丢弃，并计入 Ticker.Dropped 和 wheel 的 Stats。
+// 方法：创建缓冲为 2 的 ticker，不读取 channel 等待多个周期，检查缓冲已满且丢弃计数大于 0 并与 Stats 一致。
+func TestTickerDropCountsLostTicks(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	ticker := w.NewTicker(2*testTick, WithBuffer(2))
+
+	requireEventually(t, 200*time.Millisecond, func() bool {
+		return ticker.Dropped() >= 3
+	}, "ticker did not drop ticks")
+	requireEventually(t, 100*time.Millisecond, ticker.Stop, "ticker did not stop")
+
+	if got := len(ticker.C); got != 2 {
+		t.Fatalf("len(ticker.C) = %d, expected buffer 2 to be full", got)
+	}
+	if got, expected := w.Stats().Dropped, ticker.Dropped(); got != expected {
+		t.Fatalf("Stats().Dropped = %d, expected %d", got, expected)
+	}
+}
+
+// TestTickerCoalesceKeepsLatest 测试 coalesce 策略。
+// 功能点：channel 满时应丢弃旧的 tick 保留最新的 tick。
+// 方法：不读取 channel 等待多个周期后停止 ticker，channel 中剩下的 tick 应接近停止时间而不是第一次 tick 的时间。
+func TestTickerCoalesceKeepsLatest(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	start := time.Now()
+	ticker := w.NewTicker(2*testTick, WithCoalesce())
+
+	requireEventually(t, 200*time.Millisecond, func() bool {
+		return ticker.Dropped() >= 5
+	}, "coalescing ticker did not replace ticks")
+	requireEventually(t, 100*time.Millisecond, ticker.Stop, "ticker did not stop")
+
+	tm := waitTime(t, ticker.C, 10*time.Millisecond, "coalesced tick")
+	if tm.Sub(start) < 10*testTick {
+		t.Fatalf("kept tick at %s after start, expected a recent tick after %s", tm.Sub(start), 10*testTick)
+	}
+}
+
+// TestTickerBlockTimeoutWaitsForConsumer 测试 block-with-timeout 策略。
+// 功能点：消费者短暂跟不上时，发送方应阻塞等待而不是丢弃，Dropped 保持为 0。
+// 方法：timeout 远大于周期，读取前先等待几个周期，再连续读取多个 tick 并检查没有丢弃。
+func TestTickerBlockTimeoutWaitsForConsumer(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	ticker := w.NewTicker(2*testTick, WithBlockTimeout(time.Second))
+
+	time.Sleep(10 * testTick)
+	for i := 0; i < 4; i++ {
+		waitTime(t, ticker.C, 200*time.Millisecond, "blocking ticker")
+	}
+	//发送方可能正阻塞在 channel 上, 边读边 Stop
+	requireEventually(t, 100*time.Millisecond, func() bool {
+		select {
+		case <-ticker.C:
+		default:
+		}
+		return ticker.Stop()
+	}, "ticker did not stop")
+	if got := ticker.Dropped(); got != 0 {
+		t.Fatalf("Dropped() = %d, expected 0", got)
+	}
+}
@@ -5,8 +5,10 @@ import (
 )
 
 type Timer struct {
-	C <-chan time.Time
-	r *timer
+	C   <-chan time.Time
+	c   chan time.Time //和 C 是同一个 channel, Release 之后放回 pool
+	r   *timer
+	gen uint32 //创建时 r 的 generation, r 被 Release 后所有操作都不再生效
 }
 
 func After(d time.Duration) <-chan time.Time {
@@ -29,19 +31,24 @@ func NewTimer(d time.Duration) *Timer {
 	return defaultWheelShard.NewTimer(d)
 }
 
+// Reset/Stop/Release 在持有 w.Lock 时检查 generation, Release 之后旧的 Timer 上的操作都不再生效
+
 func (t *Timer) Reset(d time.Duration) bool {
 	//return t.r.w.resetTimer(t.r, d, 0)
-	return t.r.ResetTimer(d, 0)
+	return t.r.w.resetTimer(t.r, t.gen, d, 0)
 }
 
 func (t *Timer) Stop() bool {
 	//return t.r.w.delTimer(t.r)
-	return t.r.Stop()
+	return t.r.stop(t.gen) == nil
 }
 
+// Release 之后旧的 Timer 上的操作都不再生效; 开启 WithWrapperPool 时 t 和 t.C 会被复用, 不能再使用
 func (t *Timer) Release() {
 	//t.r.w.releaseTimer(t.r)
-	t.r.Release()
+	if t.r.releaseWrapped(t.gen) {
+		t.recycle()
+	}
 }
 
 // 需要传入callback的接口，应该用NewWheelTimerFunc 接口, 而不是NewTimerFunc
@@ -50,6 +57,19 @@ func NewTimerFunc(d time.Duration, callback func(time.Time, ...interface{}), arg
 	return defaultWheelShard.NewTimerFunc(d, callback, arg...)
 }
 
+// SetSlack 见 WheelTimer.SetSlack
+func (t *Timer) SetSlack(d time.Duration) {
+	t.r.w.setSlack(t.r, t.gen, d)
+}
+
 func (t *Timer) Info() string {
+	if !t.r.live(t.gen) {
+		return ErrReleased.Error()
+	}
 	return t.r.Info()
 }
+
+// Handle 返回带 generation 的 handle, 见 WheelTimer.Handle
+func (t *Timer) Handle() Handle {
+	return Handle{t: t.r, gen: t.gen}
+}
@@ -0,0 +1,158 @@
+package timer
+
+import "time"
+
+// TryXXX 是返回 error 的版本: 时间轮已经 Stop 时返回 ErrWheelStopped, 而不是返回一个永远不会触发的 timer;
+// Stop/Reset/Release 失败时返回 ErrTimerFired、ErrTimerActive 或 ErrTimerReleased, 而不是只返回 false 或者 Fatalf。
+
+// start 把新创建的 timer 加入时间轮, 时间轮已经 Stop 或者 timer 数量达到上限时把 timer 放回 pool
+func (w *Wheel) start(r *timer) error {
+	gen := r.curGen()
+	if err := w.addTimerE(r, gen, true); err != nil {
+		if err == ErrWheelStopped || err == ErrTooManyTimers {
+			w.releaseTimerE(r, gen)
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *Wheel) TryNewTimer(d time.Duration) (*Timer, error) {
+	t := w.chanTimer(d)
+	if err := w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (w *Wheel) TryAfterFunc(d time.Duration, f func()) (*Timer, error) {
+	t := w.afterFunc(d, f)
+	if err := w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (w *Wheel) TryNewTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) (*Timer, error) {
+	t := w.timerFunc(d, f, arg...)
+	if err := w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (w *Wheel) TryNewTicker(d time.Duration, opts ...TickerOption) (*Ticker, error) {
+	t := w.chanTicker(d, opts...)
+	if err := w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (w *Wheel) TryTickFunc(d time.Duration, f func(), opts ...TickerOption) (*Ticker, error) {
+	t := w.tickFunc(d, f, opts...)
+	if err := w.start(t.r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (w *Wheel) TryNewWheelTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) (*WheelTimer, error) {
+	t := w.newTimer(d, 0, f, arg...)
+	if err := w.start(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// TryStop 成功返回 nil; timer 已经到期返回 ErrTimerFired, 已经 Release 返回 ErrTimerReleased
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
+func (t *timer) TryStop() error {
+	return t.stop(t.curGen())
+}
+
+// stop 是带 generation 的 TryStop, Timer/Ticker/Handle 传入创建时的 generation
+func (t *timer) stop(gen uint32) error {
+	w := t.w
+	if err := w.delTimerE(t, gen); err != nil {
+		return err
+	}
+	if w.autoRelease && t.period == 0 {
+		w.releaseTimer(t, gen)
+	}
+	return nil
+}
+
+// TryResetTimer 和 ResetTimer 一样只能 Reset 还没有到期或者已经 Stop 的 timer, 时间轮已经 Stop 返回 ErrWheelStopped
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
+func (t *timer) TryResetTimer(d time.Duration, period time.Duration) error {
+	return t.w.resetTimerE(t, t.curGen(), d, period, true)
+}
+
+// TryRelease timer 还在时间轮中返回 ErrTimerActive, 已经 Release 返回 ErrTimerReleased
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
+func (t *timer) TryRelease() error {
+	return t.tryRelease(t.curGen())
+}
+
+func (t *timer) tryRelease(gen uint32) error {
+	if t.w.autoRelease && t.period == 0 {
+		if !t.live(gen) {
+			return ErrTimerReleased
+		}
+		return nil
+	}
+	return t.w.releaseTimerE(t, gen)
+}
+
+func (t *Timer) TryStop() error {
+	return t.r.stop(t.gen)
+}
+
+func (t *Timer) TryReset(d time.Duration) error {
+	return t.r.w.resetTimerE(t.r, t.gen, d, 0, true)
+}
+
+func (t *Timer) TryRelease() error {
+	return t.r.tryRelease(t.gen)
+}
+
+func (t *Ticker) TryStop() error {
+	return t.r.stop(t.gen)
+}
+
+func (t *Ticker) TryReset(d time.Duration) error {
+	return t.r.w.resetTimerE(t.r, t.gen, d, d, true)
+}
+
+func (t *Ticker) TryRelease() error {
+	return t.r.tryRelease(t.gen)
+}
+
+// wheel_shard
+
+func (ws *wheel_shard) TryNewTimer(d time.Duration) (*Timer, error) {
+	pid := ws.GetPid()
+	return ws.wheels[pid].TryNewTimer(d)
+}
+
+func (ws *wheel_shard) TryAfterFunc(d time.Duration, f func()) (*Timer, error) {
+	pid := ws.GetPid()
+	return ws.wheels[pid].TryAfterFunc(d, f)
+}
+
+func (ws *wheel_shard) TryNewTicker(d time.Duration, opts ...TickerOption) (*Ticker, error) {
+	pid := ws.GetPid()
+	return ws.wheels[pid].TryNewTicker(d, opts...)
+}
+
+func (ws *wheel_shard) TryNewWheelTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) (*WheelTimer, error) {
+	pid := ws.GetPid()
+	return ws.wheels[pid].TryNewWheelTimerFunc(d, f, arg...)
+}
@@ -0,0 +1,86 @@
+package timer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTryNewOnStoppedWheel 测试在已经 Stop 的时间轮上创建 timer。
+// 功能点：TryXXX 应返回 ErrWheelStopped 和 nil，而不是返回一个永远不会触发的 timer。
+// 方法：Stop 时间轮后依次调用各个 TryNew 接口，检查返回的 error 和对象。
+func TestTryNewOnStoppedWheel(t *testing.T) {
+	w := NewWheel(testTick, WithLogger(benchDiscardLogger{}))
+	w.Stop()
+
+	if tm, err := w.TryNewTimer(time.Millisecond); tm != nil || err != ErrWheelStopped {
+		t.Fatalf("TryNewTimer() = %v, %v, expected nil, ErrWheelStopped", tm, err)
+	}
+	if tm, err := w.TryAfterFunc(time.Millisecond, func() {}); tm != nil || err != ErrWheelStopped {
+		t.Fatalf("TryAfterFunc() = %v, %v, expected nil, ErrWheelStopped", tm, err)
+	}
+	if tk, err := w.TryNewTicker(time.Millisecond); tk != nil || err != ErrWheelStopped {
+		t.Fatalf("TryNewTicker() = %v, %v, expected nil, ErrWheelStopped", tk, err)
+	}
+	if wt, err := w.TryNewWheelTimerFunc(time.Millisecond, func(time.Time, ...interface{}) {}); wt != nil || err != ErrWheelStopped {
+		t.Fatalf("TryNewWheelTimerFunc() = %v, %v, expected nil, ErrWheelStopped", wt, err)
+	}
+	if got := w.Timers(); got != 0 {
+		t.Fatalf("Timers() = %d, expected 0", got)
+	}
+}
+
+// TestTryStopResetReleaseErrors 测试 TryStop/TryResetTimer/TryRelease 返回的错误类型。
+// 功能点：还在时间轮中的 timer 不能 Release(ErrTimerActive)；已经到期的 timer 不能 Stop/Reset(ErrTimerFired)；
+// 重复 Release 返回 ErrTimerReleased。
+// 方法：创建 callback timer，在各个状态下调用 Try 接口检查 error。
+func TestTryStopResetReleaseErrors(t *testing.T) {
+	w := newTestWheel(t, testTick)
+	done := make(chan struct{}, 1)
+	tm, err := w.TryNewWheelTimerFunc(3*testTick, func(time.Time, ...interface{}) {
+		done <- struct{}{}
+	})
+	if err != nil {
+		t.Fatalf("TryNewWheelTimerFunc() err = %v, expected nil", err)
+	}
+
+	if err := tm.TryRelease(); !errors.Is(err, ErrTimerActive) {
+		t.Fatalf("TryRelease() of pending timer err = %v, expected ErrTimerActive", err)
+	}
+	waitStruct(t, done, 200*time.Millisecond, "try timer callback")
+	requireEventually(t, 100*time.Millisecond, func() bool {
+		return tm.TryStop() == ErrTimerFired
+	}, "TryStop of fired timer did not return ErrTimerFired")
+	if err := tm.TryResetTimer(time.Millisecond, 0); err != ErrTimerFired {
+		t.Fatalf("TryResetTimer() of fired timer err = %v, expected ErrTimerFired", err)
+	}
+	if err := tm.TryRelease(); err != nil {
+		t.Fatalf("TryRelease() of fired timer err = %v, expected nil", err)
+	}
+	if err := tm.TryRelease(); err != ErrTimerReleased {
+		t.Fatalf("second TryRelease() err = %v, expected ErrTimerReleased", err)
+	}
+	assertWheelEmpty(t, w)
+}
+
+// TestViolationHandlerInsteadOfExit 测试 violation handler。
+// 功能点：Release 还在时间轮中的 timer 属于使用错误，应调用设置的 handler 而不是 Fatalf 退出进程，
+// handler 返回后 timer 仍然完好地留在时间轮中。
+// 方法：设置记录 error 的 handler，Release 一个未到期的 timer，检查 handler 收到 ErrTimerActive 且 timer 仍能 Stop。
+func TestViolationHandlerInsteadOfExit(t *testing.T) {
+	var got []error
+	w := newTestWheel(t, testTick, WithViolationHandler(func(err error) {
+		got = append(got, err)
+	}))
+
+	tm := w.NewWheelTimerFunc(time.Second, func(time.Time, ...interface{}) {})
+	tm.Release()
+	if len(got) != 1 || !errors.Is(got[0], ErrTimerActive) {
+		t.Fatalf("violations = %v, expected one ErrTimerActive", got)
+	}
+	if !tm.Stop() {
+		t.Fatalf("Stop after rejected Release returned false, expected true")
+	}
+	tm.Release()
+	assertWheelEmpty(t, w)
+}
@@ -0,0 +1,199 @@
+package timer
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StuckTimer 是 watchdog 发现的执行时间超过预算、仍在执行的 callback
+type StuckTimer struct {
+	Timer   *WheelTimer   //正在执行的 timer, 只用于识别, 不要在 hook 中 Release
+	Info    string        //timer.Info()
+	Running time.Duration //已经执行了多久
+	Stack   string        //执行 callback 的 goroutine 的调用栈
+}
+
+// WithWatchdog 开启 callback watchdog: callback 执行超过 budget 时, 即使还没有返回, 也会调用 hook 报告
+// timer 和执行它的 goroutine 的调用栈, 并计入 Stats().Stuck。每次执行最多报告一次。
+// hook 为 nil 时用 logger 输出告警。budget 同时替代默认的 maxTimerCbTake 作为 callback 返回后的告警阈值。
+func WithWatchdog(budget time.Duration, hook func(StuckTimer)) Option {
+	if budget <= 0 {
+		panic("watchdog budget must be greater than 0")
+	}
+	return func(w *Wheel) {
+		w.cbBudget = budget
+		w.watchdog = &watchdog{hook: hook, records: make(map[*execRecord]struct{})}
+	}
+}
+
+type watchdog struct {
+	hook func(StuckTimer)
+
+	sync.Mutex
+	records map[*execRecord]struct{}
+}
+
+// execRecord 记录一个执行 timer 的 goroutine 当前正在执行的 callback
+type execRecord struct {
+	wd       *watchdog
+	gid      uint64
+	t        *timer
+	start    time.Time
+	reported bool
+
+	//spawn 启动的 goroutine 执行时 t 可能已经被重新调度或者复用, Info 用执行前取出的字段生成
+	async   bool
+	expires uint64
+	period  uint64
+	arg     [1]interface{}
+}
+
+func (wd *watchdog) register() *execRecord {
+	rec := &execRecord{wd: wd, gid: goroutineID()}
+	wd.Lock()
+	wd.records[rec] = struct{}{}
+	wd.Unlock()
+	return rec
+}
+
+func (wd *watchdog) unregister(rec *execRecord) {
+	wd.Lock()
+	delete(wd.records, rec)
+	wd.Unlock()
+}
+
+func (rec *execRecord) begin(t *timer, start time.Time) {
+	wd := rec.wd
+	wd.Lock()
+	rec.t = t
+	rec.start = start
+	rec.reported = false
+	wd.Unlock()
+}
+
+// end 需要在周期 timer 重新加入时间轮之前调用, 保证 watchdog 读 timer 时 callback 还没返回
+func (rec *execRecord) end() {
+	wd := rec.wd
+	wd.Lock()
+	rec.t = nil
+	wd.Unlock()
+}
+
+func (rec *execRecord) info() string {
+	if rec.async {
+		return timerInfo(rec.expires, rec.period, rec.arg[:])
+	}
+	return rec.t.Info()
+}
+
+// spawn 代替 goFunc/goInfoFunc 在新的 goroutine 中执行 AfterFunc/TickFunc 的 f, 并在这个 goroutine 中登记执行记录,
+// f 执行超过预算一样会被报告。t.f 本身马上返回, runList 中的记录看不到 f 的执行时间
+func (wd *watchdog) spawn(w *Wheel, t *timer, start time.Time) {
+	var f func()
+	if g, ok := t.arg[0].(func(FireInfo)); ok {
+		fi := w.fireInfo(t, start)
+		f = func() { g(fi) }
+	} else {
+		f = t.arg[0].(func())
+	}
+	expires, period, arg := t.expires, t.period, t.arg[0]
+	go func() {
+		rec := wd.register()
+		defer wd.unregister(rec)
+		wd.Lock()
+		rec.t, rec.start = t, start
+		rec.async, rec.expires, rec.period, rec.arg[0] = true, expires, period, arg
+		wd.Unlock()
+		f()
+	}()
+}
+
+func (wd *watchdog) run(w *Wheel) {
+	interval := w.cbBudget / 2
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			wd.check(w)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+func (wd *watchdog) check(w *Wheel) {
+	now := time.Now()
+	var stuck []StuckTimer
+	var gids []uint64
+
+	wd.Lock()
+	for rec := range wd.records {
+		if rec.t == nil || rec.reported {
+			continue
+		}
+		if running := now.Sub(rec.start); running > w.cbBudget {
+			rec.reported = true
+			stuck = append(stuck, StuckTimer{Timer: rec.t, Info: rec.info(), Running: running})
+			gids = append(gids, rec.gid)
+		}
+	}
+	wd.Unlock()
+
+	if len(stuck) == 0 {
+		return
+	}
+	stacks := allStacks()
+	for i := range stuck {
+		stuck[i].Stack = goroutineStack(stacks, gids[i])
+		atomic.AddUint64(&w.stuck, 1)
+		if wd.hook != nil {
+			wd.hook(stuck[i])
+		} else {
+			w.log.Warnf("timer:%s cb still running after %v, over budget:%v\n%s", stuck[i].Info, stuck[i].Running, w.cbBudget, stuck[i].Stack)
+		}
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID 从 runtime.Stack 的第一行 "goroutine 18 [running]:" 中解析当前 goroutine 的 id
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func goroutineStack(stacks []byte, gid uint64) string {
+	prefix := []byte("goroutine " + strconv.FormatUint(gid, 10) + " [")
+	for _, g := range bytes.Split(stacks, []byte("\n\n")) {
+		if bytes.HasPrefix(g, prefix) {
+			return string(g)
+		}
+	}
+	return ""
+}
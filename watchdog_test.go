@@ -0,0 +1,102 @@
+package timer
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWatchdogReportsStuckCallback 测试 watchdog 在 callback 仍在执行时报告超时。
+// 功能点：callback 执行超过预算时，hook 应在 callback 返回之前被调用，报告 timer 和执行 goroutine 的调用栈，
+// 并计入 Stats().Stuck；同一次执行只报告一次，执行很快的 callback 不报告。
+// 方法：创建阻塞的 callback timer 和立即返回的 callback timer，等待 hook 收到报告后再放行阻塞的 callback。
+func TestWatchdogReportsStuckCallback(t *testing.T) {
+	const budget = 20 * time.Millisecond
+	reports := make(chan StuckTimer, 4)
+	w := newTestWheel(t, testTick, WithWatchdog(budget, func(st StuckTimer) {
+		reports <- st
+	}), WithLogger(benchDiscardLogger{}))
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	w.NewWheelTimerFunc(2*testTick, func(time.Time, ...interface{}) {})
+	stuck := w.NewWheelTimerFunc(2*testTick, func(time.Time, ...interface{}) {
+		<-release
+		close(done)
+	}, "stuck-arg")
+
+	var st StuckTimer
+	select {
+	case st = <-reports:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("watchdog did not report stuck callback")
+	}
+	select {
+	case <-done:
+		t.Fatalf("callback finished before report, expected report while running")
+	default:
+	}
+	if st.Timer != stuck || !strings.Contains(st.Info, "stuck-arg") {
+		t.Fatalf("reported timer %p info %q, expected %p with stuck-arg", st.Timer, st.Info, stuck)
+	}
+	if st.Running < budget {
+		t.Fatalf("Running = %s, expected at least %s", st.Running, budget)
+	}
+	if !strings.Contains(st.Stack, "TestWatchdogReportsStuckCallback") {
+		t.Fatalf("Stack does not contain the callback frame:\n%s", st.Stack)
+	}
+	if got := w.Stats().Stuck; got != 1 {
+		t.Fatalf("Stats().Stuck = %d, expected 1", got)
+	}
+
+	time.Sleep(2 * budget)
+	close(release)
+	waitStruct(t, done, 100*time.Millisecond, "stuck callback")
+	select {
+	case st = <-reports:
+		t.Fatalf("unexpected second report for %s, expected one report per execution", st.Info)
+	default:
+	}
+	assertWheelEmpty(t, w)
+}
+
+// TestWatchdogReportsStuckAfterFunc 测试 watchdog 报告 AfterFunc/TickFunc 中卡住的 f。
+// 功能点：AfterFunc/TickFunc 的 f 在新的 goroutine 中执行，timer 的 callback 马上返回，
+// watchdog 仍然应该在 f 执行超过预算时报告，调用栈是执行 f 的 goroutine。
+// 方法：AfterFunc 和 TickFunc 的 f 都阻塞，等待两次报告，检查调用栈包含 f 的函数帧后再放行。
+func TestWatchdogReportsStuckAfterFunc(t *testing.T) {
+	const budget = 20 * time.Millisecond
+	reports := make(chan StuckTimer, 4)
+	w := newTestWheel(t, testTick, WithWatchdog(budget, func(st StuckTimer) {
+		reports <- st
+	}), WithLogger(benchDiscardLogger{}))
+
+	release := make(chan struct{})
+	blockAfterFunc := func() { <-release }
+	w.AfterFunc(2*testTick, blockAfterFunc)
+	var blocked int32
+	blockTickFunc := func() {
+		//只有第一次执行阻塞
+		if atomic.CompareAndSwapInt32(&blocked, 0, 1) {
+			<-release
+		}
+	}
+	ticker := w.TickFunc(2*testTick, blockTickFunc)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case st := <-reports:
+			if !strings.Contains(st.Stack, "TestWatchdogReportsStuckAfterFunc") {
+				t.Fatalf("Stack does not contain the f frame:\n%s", st.Stack)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("watchdog reported %d stuck f, expected 2", i)
+		}
+	}
+	if got := w.Stats().Stuck; got != 2 {
+		t.Fatalf("Stats().Stuck = %d, expected 2", got)
+	}
+	close(release)
+	requireEventually(t, 100*time.Millisecond, ticker.Stop, "ticker did not stop")
+}
@@ -1,23 +1,27 @@
 package timer
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/jursonmo/timer/ilist"
 	"github.com/jursonmo/timer/log"
 )
 
 const (
-	tvn_bits uint64 = 6
-	tvr_bits uint64 = 8
-	tvn_size uint64 = 64  //1 << tvn_bits
-	tvr_size uint64 = 256 //1 << tvr_bits
-
-	tvn_mask uint64 = 63  //tvn_size - 1
-	tvr_mask uint64 = 255 //tvr_size -1
+	//默认的时间轮层级: tv1 有 1<<tvr_bits 个槽, tv2..tv5 各有 1<<tvn_bits 个槽, 可以通过 WithGeometry 修改
+	tvn_bits  uint64 = 6
+	tvr_bits  uint64 = 8
+	tvn_size  uint64 = 64  //1 << tvn_bits
+	tvr_size  uint64 = 256 //1 << tvr_bits
+	tv_levels uint64 = 5
+	tvn_mask  uint64 = 63  //tvn_size - 1
+	tvr_mask  uint64 = 255 //tvr_size -1
+
+	//默认层级下 tv5 能表示的最大范围, idx >= maxWheelIdx 的 timer 放到 overflow 链表里, 等 tv5 cascade 时再重新分配
+	maxWheelIdx uint64 = 1 << (tvr_bits + 4*tvn_bits)
 
 	maxTimerCbTake = 10 * time.Millisecond
 )
@@ -44,28 +48,84 @@ List              e               e               e
 //      ------------------------------------------>|
 */
 type Wheel struct {
-	sync.Mutex
+	wheelMutex
 	name string
 	log  log.Logger
 	pad  [7]uint64 //avoid share false ?
 	//pad   [cpu.CacheLinePadSize - unsafe.Sizeof(sync.Mutex)%cpu.CacheLinePadSize]byte
 	jiffies    uint64 //jiffies atomic 读比较多，写比较少，很多读的时候其实不需要同步，但是跟sync.Mutex组成了cacheline
+	clock      int64  //最近一次 tick 的时间(UnixMilli), 由 jiffies 推算, 和 jiffies 一起每个 tick 更新, 见 Now
 	timerPool  timerPooler
 	timers     int
-	taskRuning int32 //记录正在执行timer func 的goroutine 数量
-
-	// tv1        [][]*timer
-	// tv2        [][]*timer
-	// tv3        [][]*timer
-	// tv4        [][]*timer
-	// tv5        [][]*timer
-	tv1 []ilist.List
-	tv2 []ilist.List
-	tv3 []ilist.List
-	tv4 []ilist.List
-	tv5 []ilist.List
-
-	tick time.Duration
+	taskRuning int32  //记录正在执行timer func 的goroutine 数量
+	dropped    uint64 //所有 channel ticker 丢弃的 tick 数量, 见 Stats()
+	stuck      uint64 //watchdog 发现的执行超时的 callback 数量, 见 Stats()
+
+	cbBudget     time.Duration //callback 执行时间的预算, 超过会告警
+	autoRelease  bool          //一次性 timer 由 wheel 负责 Release, 见 WithAutoRelease
+	poolWrappers bool          //Release 之后复用 Timer/Ticker 的外壳和 channel, 见 WithWrapperPool
+	onViolation  func(error)   //内部状态不一致时的处理, 见 WithViolationHandler
+	watchdog     *watchdog
+
+	waiters int32         //StopWait 等待中的数量
+	idle    chan struct{} //callback 执行完时 close, 唤醒 StopWait, 由 w.Lock 保护
+
+	ctx       context.Context //Stop 时 cancel, 是所有 ctx callback 的 parent, 见 NewWheelTimerCtxFunc
+	cancelCtx context.CancelFunc
+
+	queue *opQueue //不为 nil 时 add/Stop 走无锁队列, 见 WithQueuedAdd
+
+	maxTimers   int           //w.timers 的上限, 0 表示不限制, 见 WithMaxTimers
+	limitPolicy LimitPolicy   //达到上限时的处理方式
+	blocked     int           //BlockUntilFree 阻塞中的数量, 由 w.Lock 保护
+	freed       chan struct{} //timer 数量减少时 close, 唤醒阻塞的 add, 由 w.Lock 保护
+	blockable   int32         //用过 BlockUntilFree 时为 1, runList 才记录 dispatchers, 用 atomic
+	dispatchers sync.Map      //正在执行 runList 的 goroutine id, 这些 goroutine 中不能阻塞等待, 见 waitFree
+	rejected    uint64        //因为数量上限被拒绝的 timer 数量, 见 Stats()
+	evicted     uint64        //因为数量上限被淘汰的 timer 数量, 见 Stats()
+
+	slack    float64 //timer 允许推迟的比例, 见 WithSlack
+	wakeups  uint64  //有 timer 到期的 tick 数量, 见 Stats()
+	cascaded uint64  //cascade 时重新放置的 timer 数量, 见 Stats()
+	slacked  uint64  //因为 slack 推迟了到期时间的 timer 数量, 见 Stats()
+
+	//tv[0] 就是 tv1(root), tv[1:] 对应 tv2..tv5, 层数和每层大小由 geometry 决定
+	tv [][]timerList
+	geometry
+	//超过最高层范围的 timer, 不再截断到 0xffffffff(截断会导致超长 timer 提前触发)
+	overflow timerList
+
+	//每一层非空的槽和 timer 数量, 查找下一个非空的槽和统计 timer 数量不需要遍历链表, 见 occupancy.go
+	occ            []bitmap
+	levelTimers    []int
+	overflowTimers int
+	//tv2..tvN 每个槽和 overflow 中 expires 最早和最晚的 timer, 见 bounds, bounds[0] 不用
+	bounds         [][]bounds
+	overflowBounds bounds
+	parkedBounds   bounds
+	preciseTimers  int
+	parkedTimers   int
+	cascadeBatch   int //每次持有锁最多 cascade 的 timer 数量, 见 WithCascadeBatch
+
+	//MultiWheel 中离到期还远的 timer, 等更粗的时间轮中的 hop timer 把它移到时间轮中, 见 MultiWheel
+	multi  *MultiWheel
+	parked timerList
+
+	//精确模式: 即将到期的 precise timer 按 deadline 排序, 由 preciseTimer 在 tick 内派发
+	precise      timerList
+	preciseTimer *time.Timer
+
+	tick         time.Duration //SetTick 会修改, 不持有 w.Lock 时用 curTick 读
+	tickSeq      uint32        //每次 SetTick 加 1
+	clockBase    int64         //jiffies 为 clockJiffies 时的时间(UnixNano), tick 改变时重新设置, 见 tickClock
+	clockJiffies uint64
+	tickChanged  chan struct{}    //通知 run goroutine 重新设置 ticker 或者检查自适应 tick
+	now          func() time.Time //callback 收到的时间和 schedule 的起点, ManualWheel 中是 Advance 传入的时间
+
+	baseTick   time.Duration //自适应模式下负载高时的 tick, 见 WithAdaptiveTick
+	maxTick    time.Duration //自适应模式下负载低时的 tick, 0 表示不开启
+	lowWater   int           //timer 数量不超过 lowWater 算作负载低
+	lightSince time.Time     //负载开始变低的时间, 只在 run goroutine 中使用
 
 	quit  chan struct{}
 	close bool
@@ -90,16 +150,55 @@ func WithLogger(l log.Logger) Option {
 	}
 }
 
+// WithGeometry 设置时间轮的层级: tv1 有 1<<rootBits 个槽, 之后 levels-1 层各有 1<<levelBits 个槽。
+// 默认是 WithGeometry(8, 6, 5); 短 timer 为主的场景可以加大 rootBits, 长 timer 为主的场景可以加大 levelBits 或层数。
+// rootBits 和 levelBits 最大为 16(每层 65536 个槽), 避免一层分配过多的链表头
+func WithGeometry(rootBits, levelBits, levels int) Option {
+	if rootBits <= 0 || levelBits <= 0 || levels < 2 {
+		panic("rootBits and levelBits must be greater than 0, levels must be at least 2")
+	}
+	if rootBits > maxGeometryBits || levelBits > maxGeometryBits {
+		panic("rootBits and levelBits must not exceed 16")
+	}
+	if rootBits+(levels-1)*levelBits > 63 {
+		panic("rootBits + (levels-1)*levelBits must not exceed 63")
+	}
+	return func(w *Wheel) {
+		w.geometry = newGeometry(uint64(rootBits), uint64(levelBits), levels)
+	}
+}
+
+// WithAutoRelease 开启自动回收: 一次性 timer 在 callback 执行完后, 或者 Stop 成功后, 由 wheel 放回 pool,
+// 调用者不需要(也不能)再调用 Release, Release 会被忽略。回收后旧的 *Timer 和 Handle 失效, 直接使用
+// *WheelTimer 指针的代码在 Stop 之后不能再 ResetTimer, 应该通过 NewWheelTimerHandle 返回的 Handle 操作。
+// 周期 timer 仍需要手动 Release。
+func WithAutoRelease() Option {
+	return func(w *Wheel) {
+		w.autoRelease = true
+	}
+}
+
 func (w *Wheel) String() string {
-	return fmt.Sprintf("wheel:%s, tick:%v, timers:%v, taskRuning:%d, close:%v", w.name, w.tick, w.Timers(), atomic.LoadInt32(&w.taskRuning), w.close)
+	tick, _ := w.curTick()
+	return fmt.Sprintf("wheel:%s, tick:%v, timers:%v, taskRuning:%d, close:%v", w.name, tick, w.Timers(), atomic.LoadInt32(&w.taskRuning), w.close)
 }
 
 // tick is the time for a jiffies
 func NewWheel(tick time.Duration, opts ...Option) *Wheel {
+	w := newWheel(tick, opts...)
+	go w.run()
+	if w.watchdog != nil {
+		go w.watchdog.run(w)
+	}
+	return w
+}
+
+// newWheel 创建时间轮但不启动 tick goroutine
+func newWheel(tick time.Duration, opts ...Option) *Wheel {
 	if tick <= 0 {
 		panic("tick must be greater than 0")
 	}
-	w := new(Wheel)
+	w := &Wheel{cascadeBatch: defaultCascadeBatch}
 	for _, opt := range opts {
 		opt(w)
 	}
@@ -114,49 +213,62 @@ func NewWheel(tick time.Duration, opts ...Option) *Wheel {
 	}
 
 	w.quit = make(chan struct{})
+	w.ctx, w.cancelCtx = context.WithCancel(context.Background())
 
-	f := func(size int) []ilist.List {
-		tv := make([]ilist.List, size)
-		return tv
+	if w.levels == 0 {
+		w.geometry = defaultGeometry()
+	}
+	w.tv = make([][]timerList, w.levels)
+	w.tv[0] = make([]timerList, 1<<w.rootBits)
+	for l := 1; l < len(w.tv); l++ {
+		w.tv[l] = make([]timerList, 1<<w.levelBits)
+	}
+	w.occ = make([]bitmap, len(w.tv))
+	for l := range w.tv {
+		w.occ[l] = newBitmap(len(w.tv[l]))
+	}
+	w.levelTimers = make([]int, len(w.tv))
+	w.bounds = make([][]bounds, len(w.tv))
+	for l := 1; l < len(w.tv); l++ {
+		w.bounds[l] = make([]bounds, len(w.tv[l]))
 	}
-
-	w.tv1 = f(int(tvr_size))
-	w.tv2 = f(int(tvn_size))
-	w.tv3 = f(int(tvn_size))
-	w.tv4 = f(int(tvn_size))
-	w.tv5 = f(int(tvn_size))
 
 	w.jiffies = 0
 	w.tick = tick
-
-	go w.run()
+	w.baseTick = tick
+	w.tickChanged = make(chan struct{}, 1)
+	if w.maxTick <= tick || w.single || w.multi != nil {
+		w.maxTick = 0
+	}
+	w.clockBase = time.Now().UnixNano()
+	w.clock = w.clockBase / int64(time.Millisecond)
+	if w.cbBudget <= 0 {
+		w.cbBudget = maxTimerCbTake
+	}
+	if w.onViolation == nil {
+		w.onViolation = PanicOnViolation
+	}
+	if w.now == nil {
+		w.now = time.Now
+	}
 	return w
 }
 
 func (w *Wheel) Timers() int {
 	w.Lock()
 	defer w.Unlock()
+	w.drainLocked()
 	return w.timers
 }
 
 func (w *Wheel) RealTimers() int {
 	w.Lock()
 	defer w.Unlock()
-	timersInWheel := 0
-	f := func(lists []ilist.List) int {
-		n := 0
-		for _, list := range lists {
-			for e := list.Front(); e != nil; e = e.Next() {
-				n++
-			}
-		}
-		return n
+	w.drainLocked()
+	timersInWheel := w.overflowTimers + w.preciseTimers + w.parkedTimers
+	for _, n := range w.levelTimers {
+		timersInWheel += n
 	}
-	timersInWheel += f(w.tv5)
-	timersInWheel += f(w.tv4)
-	timersInWheel += f(w.tv3)
-	timersInWheel += f(w.tv2)
-	timersInWheel += f(w.tv1)
 	return timersInWheel
 }
 
@@ -169,176 +281,336 @@ func durationToTicks(d, tick time.Duration) uint64 {
 }
 
 func (w *Wheel) addTimerInternal(t *timer) {
-	expires := t.expires
-	idx := t.expires - w.jiffies
-
-	var tv []ilist.List
-	var i uint64
-
-	if idx < tvr_size {
-		i = expires & tvr_mask
-		tv = w.tv1
-	} else if idx < (1 << (tvr_bits + tvn_bits)) {
-		i = (expires >> tvr_bits) & tvn_mask
-		tv = w.tv2
-	} else if idx < (1 << (tvr_bits + 2*tvn_bits)) {
-		i = (expires >> (tvr_bits + tvn_bits)) & tvn_mask
-		tv = w.tv3
-	} else if idx < (1 << (tvr_bits + 3*tvn_bits)) {
-		i = (expires >> (tvr_bits + 2*tvn_bits)) & tvn_mask
-		tv = w.tv4
-	} else if int64(idx) < 0 {
-		i = w.jiffies & tvr_mask
-		tv = w.tv1
-	} else {
-		if idx > 0x00000000ffffffff {
-			idx = 0x00000000ffffffff
-
-			expires = idx + w.jiffies
-		}
-
-		i = (expires >> (tvr_bits + 3*tvn_bits)) & tvn_mask
-		tv = w.tv5
+	level, i, overflow := w.slotOf(t.expires, w.jiffies)
+	if overflow {
+		//超出所有层级的范围, 先放到 overflow, 等最高层 cascade 时再检查
+		w.overflow.PushBack(t)
+		t.list = &w.overflow
+		t.state = NotReady
+		w.overflowTimers++
+		w.overflowBounds.add(t, w.overflowTimers == 1)
+		return
 	}
-	tv[i].PushBack(t)
-	t.list = &tv[i]
+	w.link(t, level, i)
 	t.state = NotReady
 }
 
-func (w *Wheel) cascade(tv []ilist.List, index int) int {
-	var t *timer
-	list := &tv[index]
-	for !list.Empty() {
-		e := list.Front()
-		list.Remove(e)
-		t = e.(*timer)
-		w.addTimerInternal(t)
+// cascadeOverflow 在最高层 cascade 时调用, 把已经进入时间轮范围的 timer 重新加到时间轮中
+func (w *Wheel) cascadeOverflow() {
+	list := &w.overflow
+	for t := list.Front(); t != nil; {
+		next := t.Next()
+		if t.expires-w.jiffies < w.maxIdx {
+			w.unlink(t)
+			w.addTimerInternal(t)
+			atomic.AddUint64(&w.cascaded, 1)
+		}
+		t = next
 	}
-	return index
 }
 
-func (w *Wheel) getIndex(n int) int {
-	return int((w.jiffies >> (tvr_bits + uint64(n)*tvn_bits)) & tvn_mask)
+func (w *Wheel) onTick() {
+	execList := w.expire()
+
+	//检查 w.taskRuning 的合理性,如果w.tick是50ms, 那么w.taskRuning必须等于0，即50ms 内必须定时器必须执行完。
+	//如果w.tick是10ms, 那么w.taskRuning 不能大于5, 即允许还有5个任务(goroutine)在执行timer func
+	//开启 watchdog 后由 watchdog 在 callback 执行期间检查, 不再用这个粗略的估计
+	running := atomic.LoadInt32(&w.taskRuning)
+	if tick, _ := w.curTick(); w.watchdog == nil && tick*time.Duration(running) > (time.Millisecond*50) {
+		w.log.Warnf("warnning: %d task still running\n", running)
+	}
+
+	if !execList.Empty() {
+		atomic.AddInt32(&w.taskRuning, 1)
+		go w.runList(execList)
+	}
 }
 
-func (w *Wheel) onTick() {
+// expire 推进一个 tick, 返回这个 tick 到期需要执行的 timer
+func (w *Wheel) expire() timerList {
 	w.Lock()
+	//先处理 WithQueuedAdd 队列中的 add/Stop, 入队时已经到期的 timer 会放到当前的槽里, 本次就执行
+	w.drainLocked()
 
-	index := int(w.jiffies & tvr_mask)
-
-	if index == 0 && (w.cascade(w.tv2, w.getIndex(0))) == 0 &&
-		(w.cascade(w.tv3, w.getIndex(1))) == 0 &&
-		(w.cascade(w.tv4, w.getIndex(2))) == 0 &&
-		(w.cascade(w.tv5, w.getIndex(3)) == 0) {
+	index := int(w.jiffies & w.rootMask)
 
+	//tv1 转完一圈时逐层 cascade, 其他 tick 提前迁移 tv2 中下一个要 cascade 的槽
+	if !w.cascade(w.jiffies, w.cascadeSlot, w.cascadeOverflow) {
+		w.precascade()
 	}
 
 	//w.jiffies++
 	atomic.AddUint64(&w.jiffies, 1) //w.jiffies有变化时,用atomic.Add, 让其他任务可以在没有加锁的情况下,用atomic.Load来获取最新值。
-	for e := w.tv1[index].Front(); e != nil; e = e.Next() {
-		t := e.(*timer)
-		t.state = Ready
-		t.list = nil
-		w.timers--
+	w.tickClock()
+	execList := w.takeSlot(0, uint64(index))
+	for t := execList.Front(); t != nil; {
+		next := t.Next()
+		if t.precise {
+			//精确模式的 timer 不在这里执行, 按 deadline 放到 precise 队列里由 runtime timer(单 goroutine 模式下是 Advance)派发
+			execList.Remove(t)
+			w.addPrecise(t)
+		} else if !w.pullLocked(t) {
+			//已经被无锁 Stop
+			execList.Remove(t)
+			t.Entry.Reset()
+		} else {
+			t.state = Ready
+			atomic.StoreInt32(&t.busy, 1)
+			t.list = nil
+			w.untrack(t)
+		}
+		t = next
+	}
+	if !execList.Empty() {
+		atomic.AddUint64(&w.wakeups, 1)
 	}
-	execList := w.tv1[index]
-	w.tv1[index].Reset()
 	w.Unlock()
+	return execList
+}
 
-	//检查 w.taskRuning 的合理性,如果w.tick是50ms, 那么w.taskRuning必须等于0，即50ms 内必须定时器必须执行完。
-	//如果w.tick是10ms, 那么w.taskRuning 不能大于5, 即允许还有5个任务(goroutine)在执行timer func
-	running := atomic.LoadInt32(&w.taskRuning)
-	if w.tick*time.Duration(running) > (time.Millisecond * 50) {
-		w.log.Warnf("warnning: %d task still running\n", running)
+// runList 依次执行已经到期的 timer, 调用前需要 atomic.AddInt32(&w.taskRuning, 1)
+func (w *Wheel) runList(list timerList) {
+	if atomic.LoadInt32(&w.blockable) != 0 {
+		id := goroutineID()
+		w.dispatchers.Store(id, struct{}{})
+		defer w.dispatchers.Delete(id)
 	}
-
-	f := func(list ilist.List) {
-		for !list.Empty() {
-			e := list.Front()
-			list.Remove(e)
-			e.Reset()
-			t := e.(*timer)
-			t.state = Running
-			start := time.Now()
+	var rec *execRecord
+	if w.watchdog != nil {
+		rec = w.watchdog.register()
+		defer w.watchdog.unregister(rec)
+	}
+	for !list.Empty() {
+		t := list.Front()
+		list.Remove(t)
+		t.Entry.Reset()
+		t.state = Running
+		start := w.now()
+		if t.period > 0 && t.periodMode() == FixedRate {
+			atomic.StoreUint64(&t.missed, w.behind(t, start))
+		}
+		t.fires++
+		if rec != nil {
+			rec.begin(t, start)
+		}
+		gen := atomic.LoadUint32(&t.gen)
+		var next time.Duration
+		var stop bool
+		//callback 返回后一次性 timer 可能已经被 Release, 不能再读 t.dynF
+		dynamic := t.dynF != nil
+		if dynamic {
+			next, stop = t.dynF(w.fireInfo(t, start))
+		} else if t.ctxF != nil {
+			w.runCtx(t, start)
+		} else if rec != nil && t.async {
+			//f 在新的 goroutine 中执行, 由 watchdog 启动并在那个 goroutine 中记录执行时间
+			w.watchdog.spawn(w, t, start)
+		} else if t.infoF != nil {
+			t.infoF(w.fireInfo(t, start), t.arg...)
+		} else {
 			t.f(start, t.arg...)
+		}
+		if rec != nil {
+			rec.end()
+		}
 
-			//check the time of the callback taken
-			if take := time.Since(start); take > maxTimerCbTake {
-				w.log.Warnf("timer:%s cb run take:%v, over maxTimerCbTake:%v", t, take, maxTimerCbTake)
-			}
-			if t.period > 0 {
-				t.expires = t.period + atomic.LoadUint64(&w.jiffies)
-				if !w.addTimer(t) {
-					w.log.Errorf("add period timer:%+v fail", t)
-				}
+		//check the time of the callback taken
+		if take := w.now().Sub(start); take > w.cbBudget {
+			w.log.Warnf("timer:%s cb run take:%v, over budget:%v", t.Info(), take, w.cbBudget)
+		}
+		rearmed := false
+		if dynamic {
+			if !stop {
+				w.rearmDynamic(t, next, start)
+				rearmed = w.rearm(t)
 			}
+		} else if t.period > 0 {
+			w.rearmPeriodic(t)
+			rearmed = w.rearm(t)
+		}
+		//finish 之后 t 可能已经被调用者 Release 并复用, 不能再读 t 的字段
+		release := !rearmed && t.period == 0 && w.autoRelease
+		w.finish(t)
+		if release {
+			w.releaseTimer(t, gen)
 		}
-		atomic.AddInt32(&w.taskRuning, -1)
-	}
-
-	if !execList.Empty() {
-		atomic.AddInt32(&w.taskRuning, 1)
-		go f(execList)
 	}
+	atomic.AddInt32(&w.taskRuning, -1)
 }
 
 func (w *Wheel) addTimer(t *timer) bool {
+	return w.addTimerE(t, atomic.LoadUint32(&t.gen), false) == nil
+}
+
+// addTimerE 把 timer 加入时间轮; t 已经不是 gen 这一代(被 Release 过)返回 ErrTimerReleased,
+// checkClose 为 true 时, 时间轮已经 Stop 返回 ErrWheelStopped
+func (w *Wheel) addTimerE(t *timer, gen uint32, checkClose bool) error {
+	if w.queue != nil && w.maxTimers == 0 && t.group == nil {
+		return w.enqueueAdd(t, gen, checkClose)
+	}
 	w.Lock()
-	if t.list != nil {
-		//w.Unlock()
-		//return false
-		w.log.Fatalf("repeat addTimer? timer still in wheel")
+	w.drainLocked()
+	if !t.live(gen) {
+		w.Unlock()
+		return ErrTimerReleased
+	}
+	if checkClose && w.close {
+		w.Unlock()
+		return ErrWheelStopped
 	}
-	w.addTimerInternal(t)
-	w.timers++
+	evicted, err := w.admit(t)
+	if err != nil {
+		w.Unlock()
+		return err
+	}
+	err = w.addLocked(t)
 	w.Unlock()
-	return true
+	w.releaseEvicted(evicted)
+	if err != nil {
+		w.violation(err)
+		return ErrTimerActive
+	}
+	return nil
+}
+
+// addLocked 调用者持有 w.Lock
+func (w *Wheel) addLocked(t *timer) error {
+	if t.list != nil {
+		//repeat addTimer? timer still in wheel
+		return fmt.Errorf("%w: repeat addTimer, timer still in wheel", ErrTimerActive)
+	}
+	if t.tickSeq != w.tickSeq {
+		//schedule 之后 SetTick 过, expires 还是按旧的 tick 计算的
+		w.retick(t, w.now().UnixNano())
+	}
+	switch {
+	case w.multi != nil && w.multi.park(t, w.now().UnixNano(), len(w.multi.wheels)-1):
+		//离到期还远, 先等在更粗的时间轮中
+	case t.precise && t.deadline-w.now().UnixNano() < int64(w.tick):
+		//不到一个 tick 就到期, 直接放到 precise 队列
+		w.addPrecise(t)
+	default:
+		w.applySlack(t)
+		w.addTimerInternal(t)
+	}
+	if w.queue != nil {
+		t.setQs(qsArmed)
+	}
+	w.track(t)
+	if w.maxTick > 0 && w.tick != w.baseTick && w.timers > w.lowWater {
+		//自适应模式下 tick 已经变粗, timer 变多时马上通知 run goroutine 切回 baseTick
+		w.notifyTick()
+	}
+	return nil
 }
 
 // 目前只有stoped和NotReady的状态timer.Stop()才返回true,
 // todo:那道理timer excute完后可以Stop() 和 Reset(); 同时timer in sync.Pool 是不能做任何操作的
-func (w *Wheel) delTimer(t *timer) bool {
+func (w *Wheel) delTimer(t *timer, gen uint32) bool {
+	return w.delTimerE(t, gen) == nil
+}
+
+// delTimerE 删除 timer; timer 已经到期返回 ErrTimerFired, 已经 Release(不再是 gen 这一代)返回 ErrTimerReleased。
+// generation 在持有 w.Lock 时检查(无锁模式下和 qs 一起 CAS), 检查之后 timer 不会被 Release 并复用
+func (w *Wheel) delTimerE(t *timer, gen uint32) error {
+	if w.queue != nil && w.dequeueStop(t, gen) {
+		return nil
+	}
+	return w.removeTimer(t, gen)
+}
+
+// removeTimer 是加锁的 delTimerE, 返回之后 t 一定已经不在时间轮中
+func (w *Wheel) removeTimer(t *timer, gen uint32) error {
 	w.Lock()
 	defer w.Unlock()
+	w.drainLocked()
+	if !t.live(gen) {
+		return ErrTimerReleased
+	}
+	return w.delLocked(t)
+}
+
+// delLocked 调用者持有 w.Lock
+func (w *Wheel) delLocked(t *timer) error {
 	if t.state == Stoped {
-		return true
+		return nil
+	}
+	if t.state == InPool {
+		if timerDebug {
+			panic("timer: use of released timer")
+		}
+		return ErrTimerReleased
 	}
 	//如果这个timer 正准备被执行了, t.list 会被置为nil。所以t.list != nil 就说明这个timer 还没有准备被执行，可以删除。
 	if t.list != nil /*&& t.state == NotReady*/ {
-		t.list.Remove(t)
+		if t.list == &w.parked {
+			w.multi.cancelHop(t)
+		}
+		w.unlink(t)
 		t.Entry.Reset()
 		t.list = nil
 		t.state = Stoped //有w.Lock()和 t.list != nil 的保护, 所以t.state不会被onTick()任务并发修改状态。
-		w.timers--       //主动删除timer时，需要减少timers
-		return true
+		w.untrack(t)     //主动删除timer时，需要减少timers
+		if w.queue != nil {
+			t.setQs(qsNone)
+		}
+		return nil
 	}
-	return false
+	//已经到期来不及取消, 取消正在执行的 ctx callback, 见 NewWheelTimerCtxFunc
+	w.cancelRunning(t)
+	return ErrTimerFired
+}
+
+func (w *Wheel) resetTimer(t *timer, gen uint32, when time.Duration, period time.Duration) bool {
+	return w.resetTimerE(t, gen, when, period, false) == nil
 }
 
-func (w *Wheel) resetTimer(t *timer, when time.Duration, period time.Duration) bool {
-	ok := w.delTimer(t)
-	if !ok {
-		return false
+func (w *Wheel) resetTimerE(t *timer, gen uint32, when time.Duration, period time.Duration, checkClose bool) error {
+	//schedule 会修改 t 的字段, 不能用无锁 Stop 留在时间轮中等 drain 移除;
+	//检查 generation、移除和 schedule 在同一个 w.Lock 里, 不会改到别人复用的 timer, 之后 addTimerE 会再检查一次
+	w.Lock()
+	w.drainLocked()
+	if !t.live(gen) {
+		w.Unlock()
+		return ErrTimerReleased
+	}
+	if err := w.delLocked(t); err != nil {
+		w.Unlock()
+		return err
 	}
+	w.schedule(t, when, period)
+	w.Unlock()
+
+	return w.addTimerE(t, gen, checkClose)
+}
+
+// schedule 根据 when 和 period 设置 timer 的 expires/period 以及精确的 deadline/interval
+func (w *Wheel) schedule(t *timer, when time.Duration, period time.Duration) {
 	// t.expires = atomic.LoadUint64(&w.jiffies) + uint64(when/w.tick)
 	// t.period = uint64(period / w.tick)
 	//向上取整
-	t.expires = atomic.LoadUint64(&w.jiffies) + durationToTicks(when, w.tick)
-	t.period = durationToTicks(period, w.tick)
+	tick, seq := w.curTick()
+	t.expires = atomic.LoadUint64(&w.jiffies) + durationToTicks(when, tick)
+	t.period = durationToTicks(period, tick)
+	t.tickSeq = seq
 
-	return w.addTimer(t)
+	now := w.now().UnixNano()
+	t.deadline = now + int64(when)
+	t.interval = period
+	atomic.StoreUint64(&t.missed, 0)
+	t.fires = 0
+	t.stopReq = false
+	t.canceled = false
+	if t.precise {
+		w.setPreciseExpires(t, now)
+	}
 }
 
 func (w *Wheel) newTimer(when time.Duration, period time.Duration,
 	f func(time.Time, ...interface{}), arg ...interface{}) *timer {
 	//t := new(timer)
 	t := w.getTimer()
-
-	// t.expires = atomic.LoadUint64(&w.jiffies) + uint64(when/w.tick)
-	// t.period = uint64(period / w.tick)
-	//向上取整
-	t.expires = atomic.LoadUint64(&w.jiffies) + durationToTicks(when, w.tick)
-	t.period = durationToTicks(period, w.tick)
+	w.schedule(t, when, period)
 
 	t.f = f
 	t.arg = arg
@@ -348,39 +620,102 @@ func (w *Wheel) newTimer(when time.Duration, period time.Duration,
 	return t
 }
 
+// newTimerArg 和 newTimer 一样, 但唯一的参数放在 t.argBuf 中, 不需要分配 []interface{}。
+// chan、func 和指针放进 interface{} 也不需要分配
+func (w *Wheel) newTimerArg(when time.Duration, period time.Duration,
+	f func(time.Time, ...interface{}), a interface{}) *timer {
+	t := w.newTimer(when, period, f)
+	t.argBuf[0] = a
+	t.arg = t.argBuf[:]
+	return t
+}
+
 func (w *Wheel) getTimer() *timer {
 	if w.timerPool == nil {
 		return new(timer)
 	}
 	t := w.timerPool.Get()
 	//check timer and reset
-	if t.list != nil || t.f != nil || t.arg != nil {
-		w.log.Fatalf("timer is not init state")
+	if t.list != nil || t.f != nil || t.infoF != nil || t.arg != nil {
+		w.violation(fmt.Errorf("%w: timer from pool is not init state", ErrInvariant))
+		return new(timer) //丢弃这个对象, 不能让它再进入时间轮
 	}
 	if t.state != Stoped && t.state != FromPool {
-		w.log.Fatalf("t.state != Stoped && t.state != FromPool")
+		w.violation(fmt.Errorf("%w: timer from pool state %d != Stoped && != FromPool", ErrInvariant, t.state))
+		return new(timer)
 	}
 	t.state = Stoped
 	return t
 }
 
 // 并发不安全; todo:按道理只有在Stoped 状态和 timer 执行完的状态才能释放(放回到池里).(todo:timer需要加锁并修改状态)
-func (w *Wheel) releaseTimer(t *timer) {
+// 返回 t 是否真的放回了 pool, 没有 pool 时返回 false
+func (w *Wheel) releaseTimer(t *timer, gen uint32) bool {
+	switch err := w.releaseTimerE(t, gen); err {
+	case nil:
+		return w.timerPool != nil
+	case ErrTimerReleased:
+		if !t.live(gen) {
+			//过期的 handle, 不是重复 Release
+			return false
+		}
+		if timerDebug {
+			panic("timer: Release of released timer")
+		}
+		w.log.Errorf("timer already released, Release ignored")
+	default:
+		w.violation(err)
+	}
+	return false
+}
+
+// releaseTimerE 把 timer 放回 pool; timer 还在时间轮中返回 ErrTimerActive, 已经放回 pool 或者不再是 gen 这一代返回 ErrTimerReleased
+func (w *Wheel) releaseTimerE(t *timer, gen uint32) error {
 	if w.timerPool == nil {
-		return
+		return nil
+	}
+	w.Lock()
+	w.drainLocked()
+	if t.state == InPool || !t.live(gen) {
+		w.Unlock()
+		return ErrTimerReleased
 	}
 	//check timer
 	if t.list != nil {
-		w.log.Fatalf("timer is in wheel, can't be released")
+		w.Unlock()
+		return fmt.Errorf("%w: timer is in wheel, can't be released", ErrTimerActive)
 	}
 	if !t.Entry.IsInit() {
-		w.log.Fatalf("timer haven't executed")
+		w.Unlock()
+		return fmt.Errorf("%w: timer haven't executed", ErrTimerActive)
 	}
+	//旧的 handle 从此失效, qs 中的 generation 一起更新
+	atomic.AddUint32(&t.gen, 1)
+	t.setQs(qsNone)
 	//init timer
 	t.f = nil
+	t.infoF = nil
+	t.ctxF = nil
+	t.dynF = nil
+	t.async = false
+	t.group = nil
 	t.arg = nil //gc faster
+	t.argBuf[0] = nil
+	t.precise = false
+	atomic.StoreInt32(&t.mode, int32(FixedDelay))
+	atomic.StoreUint64(&t.missed, 0)
+	t.stopReq = false
+	t.slack, t.slackSet = 0, false
 	t.state = InPool
+	w.Unlock()
+
+	if timerDebug {
+		//debug 模式下不复用, 让后续对这个 timer 的任何使用都 panic
+		poisonTimer(t)
+		return nil
+	}
 	w.timerPool.Put(t)
+	return nil
 }
 
 // 如果没有实现PoolNewCounter,返回 -1
@@ -393,13 +728,27 @@ func (w *Wheel) PoolNewCount() int64 {
 
 func (w *Wheel) run() {
 	defer w.log.Infof("Wheel quit, %v", w)
-	ticker := time.NewTicker(w.tick)
+	tick, _ := w.curTick()
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case now := <-ticker.C:
 			w.onTick()
+			w.syncClock(now)
+			if w.maxTick > 0 && w.adapt(now) {
+				tick, _ = w.curTick()
+				ticker.Reset(tick)
+			}
+		case <-w.tickChanged:
+			if w.maxTick > 0 {
+				w.adapt(time.Now())
+			}
+			if cur, _ := w.curTick(); cur != tick {
+				tick = cur
+				ticker.Reset(tick)
+			}
 		case <-w.quit:
 			return
 		}
@@ -408,7 +757,11 @@ func (w *Wheel) run() {
 
 func (w *Wheel) Stop() {
 	close(w.quit)
+	w.Lock()
 	w.close = true
+	w.Unlock()
+	w.cancelCtx()
+	w.stopPrecise()
 }
 
 func sendTime(t time.Time, arg ...interface{}) {
@@ -424,6 +777,21 @@ func goFunc(t time.Time, arg ...interface{}) {
 	go arg[0].(func())()
 }
 
+func callFunc(t time.Time, arg ...interface{}) {
+	arg[0].(func())()
+}
+
+// newFuncTimer 创建 AfterFunc/TickFunc 的 timer: f 和标准库一样在新的 goroutine 中执行,
+// 单 goroutine 的时间轮(ManualWheel/SimWheel)直接执行, 保证执行顺序确定
+func (w *Wheel) newFuncTimer(when time.Duration, period time.Duration, f func()) *timer {
+	if w.single {
+		return w.newTimerArg(when, period, callFunc, f)
+	}
+	t := w.newTimerArg(when, period, goFunc, f)
+	t.async = true
+	return t
+}
+
 func dummyFunc(t time.Time, arg interface{}) {
 
 }
@@ -436,15 +804,12 @@ func (w *Wheel) Sleep(d time.Duration) {
 	<-w.NewTimer(d).C
 }
 
-func (w *Wheel) Tick(d time.Duration) <-chan time.Time {
-	return w.NewTicker(d).C
+func (w *Wheel) Tick(d time.Duration, opts ...TickerOption) <-chan time.Time {
+	return w.NewTicker(d, opts...).C
 }
 
-func (w *Wheel) TickFunc(d time.Duration, f func()) *Ticker {
-	t := &Ticker{
-		r: w.newTimer(d, d, goFunc, f),
-	}
-
+func (w *Wheel) TickFunc(d time.Duration, f func(), opts ...TickerOption) *Ticker {
+	t := w.tickFunc(d, f, opts...)
 	if w.addTimer(t.r) {
 		return t
 	}
@@ -454,10 +819,7 @@ func (w *Wheel) TickFunc(d time.Duration, f func()) *Ticker {
 }
 
 func (w *Wheel) AfterFunc(d time.Duration, f func()) *Timer {
-	t := &Timer{
-		r: w.newTimer(d, 0, goFunc, f),
-	}
-
+	t := w.afterFunc(d, f)
 	if w.addTimer(t.r) {
 		return t
 	}
@@ -466,12 +828,7 @@ func (w *Wheel) AfterFunc(d time.Duration, f func()) *Timer {
 }
 
 func (w *Wheel) NewTimer(d time.Duration) *Timer {
-	c := make(chan time.Time, 1)
-	t := &Timer{
-		C: c,
-		r: w.newTimer(d, 0, sendTime, c),
-	}
-
+	t := w.chanTimer(d)
 	if w.addTimer(t.r) {
 		return t
 	}
@@ -479,13 +836,8 @@ func (w *Wheel) NewTimer(d time.Duration) *Timer {
 	return nil
 }
 
-func (w *Wheel) NewTicker(d time.Duration) *Ticker {
-	c := make(chan time.Time, 1)
-	t := &Ticker{
-		C: c,
-		r: w.newTimer(d, d, sendTime, c),
-	}
-
+func (w *Wheel) NewTicker(d time.Duration, opts ...TickerOption) *Ticker {
+	t := w.chanTicker(d, opts...)
 	if w.addTimer(t.r) {
 		return t
 	}
@@ -495,10 +847,7 @@ func (w *Wheel) NewTicker(d time.Duration) *Ticker {
 
 // add by mo
 func (w *Wheel) NewTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) *Timer {
-	t := &Timer{
-		r: w.newTimer(d, 0, f, arg...),
-	}
-
+	t := w.timerFunc(d, f, arg...)
 	if w.addTimer(t.r) {
 		return t
 	}
@@ -515,3 +864,47 @@ func (w *Wheel) NewWheelTimerFunc(d time.Duration, f func(time.Time, ...interfac
 
 	return nil
 }
+
+// 下面几个函数只创建对象, 不加入时间轮, 由 NewXXX 和 TryNewXXX 共用
+
+func (w *Wheel) tickFunc(d time.Duration, f func(), opts ...TickerOption) *Ticker {
+	t := w.getTicker()
+	t.r = w.newFuncTimer(d, d, f)
+	t.gen = t.r.gen
+	newTickerConfig(opts).apply(t.r)
+	return t
+}
+
+func (w *Wheel) afterFunc(d time.Duration, f func()) *Timer {
+	r := w.newFuncTimer(d, 0, f)
+	return w.newTimerWrapper(r, nil)
+}
+
+func (w *Wheel) chanTimer(d time.Duration) *Timer {
+	c := w.getTimeChan()
+	r := w.newTimerArg(d, 0, sendTime, c)
+	return w.newTimerWrapper(r, c)
+}
+
+func (w *Wheel) chanTicker(d time.Duration, opts ...TickerOption) *Ticker {
+	cfg := newTickerConfig(opts)
+	var c chan time.Time
+	if cfg.buffer == 1 {
+		c = w.getTimeChan()
+	} else {
+		c = make(chan time.Time, cfg.buffer)
+	}
+	t := w.getTicker()
+	t.C, t.c = c, c
+	t.sender = tickSender{c: c, policy: cfg.policy, timeout: cfg.timeout, w: w}
+	t.s = &t.sender
+	t.r = w.newTimerArg(d, d, sendTick, t.s)
+	t.gen = t.r.gen
+	cfg.apply(t.r)
+	return t
+}
+
+func (w *Wheel) timerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) *Timer {
+	r := w.newTimer(d, 0, f, arg...)
+	return w.newTimerWrapper(r, nil)
+}
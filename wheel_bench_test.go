@@ -2,6 +2,7 @@ package timer
 
 import (
 	"fmt"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -203,3 +204,230 @@ func BenchmarkWheelShardNewTimerParallel(b *testing.B) {
 		}
 	})
 }
+
+var benchGeometries = []struct {
+	name                       string
+	rootBits, levelBits, level int
+}{
+	{name: "8-6-5", rootBits: 8, levelBits: 6, level: 5},
+	{name: "12-6-4", rootBits: 12, levelBits: 6, level: 4},
+	{name: "6-8-4", rootBits: 6, levelBits: 8, level: 4},
+	{name: "4-4-8", rootBits: 4, levelBits: 4, level: 8},
+}
+
+// BenchmarkGeometryAdd 对比不同层级下添加/删除 timer 的开销, delay 覆盖短 timer 和长 timer。
+func BenchmarkGeometryAdd(b *testing.B) {
+	delays := []time.Duration{5 * time.Millisecond, 200 * time.Millisecond, 30 * time.Second, benchDelay}
+	for _, g := range benchGeometries {
+		b.Run(g.name, func(b *testing.B) {
+			w := NewWheel(benchTick, WithLogger(benchDiscardLogger{}), WithGeometry(g.rootBits, g.levelBits, g.level))
+			defer w.Stop()
+			f := func(time.Time, ...interface{}) {}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				timer := w.NewWheelTimerFunc(delays[i%len(delays)], f)
+				stopReleaseWheelTimer(b, timer)
+			}
+		})
+	}
+}
+
+// BenchmarkGeometryTick 对比不同层级下 onTick(包括 cascade) 的开销。
+// 时间轮中保持固定数量的周期 timer, 由 benchmark 手动驱动 onTick。
+func BenchmarkGeometryTick(b *testing.B) {
+	const timers = 1 << 14
+	for _, g := range benchGeometries {
+		b.Run(g.name, func(b *testing.B) {
+			//tick 为 1 小时, 内部 run goroutine 不会触发 onTick
+			w := NewWheel(time.Hour, WithLogger(benchDiscardLogger{}), WithGeometry(g.rootBits, g.levelBits, g.level))
+			defer w.Stop()
+			f := func(time.Time, ...interface{}) {}
+			for i := 0; i < timers; i++ {
+				t := w.newTimer(0, 0, f)
+				t.period = uint64(i*37%(1<<16)) + 1
+				t.expires = t.period
+				w.addTimer(t)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w.onTick()
+			}
+		})
+	}
+}
+
+// BenchmarkAddStopParallel 对比并发 add/Stop 时加锁和 WithQueuedAdd 无锁队列的开销。
+// 只 add 和 Stop 不 Release, Release 两种模式都需要加锁。
+func BenchmarkAddStopParallel(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "mutex"},
+		{name: "queued", opts: []Option{WithQueuedAdd()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			w := NewWheel(benchTick, append([]Option{WithLogger(benchDiscardLogger{})}, bc.opts...)...)
+			defer w.Stop()
+			f := func(time.Time, ...interface{}) {}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					timer := w.NewWheelTimerFunc(benchDelay, f)
+					if !timer.Stop() {
+						b.Fatal("timer fired before Stop")
+					}
+				}
+			})
+			b.StopTimer()
+			if got := w.Timers(); got != 0 {
+				b.Fatalf("Timers() = %d, expected 0", got)
+			}
+		})
+	}
+}
+
+// BenchmarkAddParallelWithTick 对比 tick 持续进行时并发 add 的开销, timer 都会到期执行。
+func BenchmarkAddParallelWithTick(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "mutex"},
+		{name: "queued", opts: []Option{WithQueuedAdd()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			w := NewWheel(benchTick, append([]Option{WithLogger(benchDiscardLogger{})}, bc.opts...)...)
+			defer w.Stop()
+			f := func(time.Time, ...interface{}) {}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					w.NewWheelTimerFunc(5*benchTick, f)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkCascadeExpire 测试 cascade 和取出到期 timer 的开销: 大量 timer 落在 tv2 的同一个槽里,
+// 逐 tick 推进到这些 timer 全部到期取出(不执行 callback), 报告平均每个 timer 的开销。
+func BenchmarkCascadeExpire(b *testing.B) {
+	const timers = 1 << 16
+	for _, bc := range []struct {
+		name  string
+		batch int
+	}{
+		{name: "whole-slot", batch: 0},
+		{name: "batched", batch: defaultCascadeBatch},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			f := func(time.Time, ...interface{}) {}
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				w := newWheel(benchTick, WithLogger(benchDiscardLogger{}), WithCascadeBatch(bc.batch))
+				for j := 0; j < timers; j++ {
+					t := w.newTimer(0, 0, f)
+					t.expires = 1280 + uint64(j%256) //tv2 的第 5 个槽
+					w.addTimer(t)
+				}
+				b.StartTimer()
+				for w.jiffies < 1536 {
+					w.expire()
+				}
+				b.StopTimer()
+				w.cancelCtx()
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*timers), "ns/timer")
+		})
+	}
+}
+
+// BenchmarkNextExpiry 测试 ManualWheel 查找最早到期时间的开销: 大量 timer 落在 tv2 的同一个槽里。
+// cached 是槽里缓存的最早 timer 没有变化时的查询; stop-min 每次先 Stop 最早的 timer 再查询,
+// 是需要遍历整个槽重新计算的最坏情况
+func BenchmarkNextExpiry(b *testing.B) {
+	const timers = 1 << 12
+	for _, stopMin := range []bool{false, true} {
+		name := "cached"
+		if stopMin {
+			name = "stop-min"
+		}
+		b.Run(name, func(b *testing.B) {
+			m := NewManualWheel(benchTick, time.Unix(0, 0), WithLogger(benchDiscardLogger{}))
+			defer m.Stop()
+			w := m.w
+			f := func(time.Time, ...interface{}) {}
+			ts := make([]*timer, timers)
+			for j := range ts {
+				ts[j] = w.newTimer(0, 0, f)
+				ts[j].expires = 1280 + uint64(j%256) //tv2 的第 5 个槽
+				w.addTimer(ts[j])
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if stopMin {
+					b.StopTimer()
+					min, _ := w.bounds[1][5].get(&w.tv[1][5])
+					w.delTimer(min, min.curGen())
+					w.addTimer(min)
+					b.StartTimer()
+				}
+				if _, ok := w.nextExpiry(); !ok {
+					b.Fatal("nextExpiry() ok = false, expected true")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPendingTimersGC 对比大量 pending timer 时 sync.Pool 路径和 SlabWheel 的 GC 开销:
+// 时间轮中保持 1<<20 个 timer, 每次迭代做一次完整的 GC, ns/op 就是一次 GC 的时间,
+// 同时报告 GC 之后的堆大小和平均 STW 暂停时间。
+func BenchmarkPendingTimersGC(b *testing.B) {
+	const timers = 1 << 20
+	gc := func(b *testing.B) {
+		runtime.GC()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			runtime.GC()
+		}
+		b.StopTimer()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc)/(1<<20), "heap-MB")
+		if n := after.NumGC - before.NumGC; n > 0 {
+			b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(n), "pause-ns")
+		}
+	}
+
+	b.Run("pool", func(b *testing.B) {
+		//tick 为 1 小时, timer 不会到期
+		w := NewWheel(time.Hour, WithLogger(benchDiscardLogger{}))
+		defer w.Stop()
+		f := func(time.Time, ...interface{}) {}
+		for i := 0; i < timers; i++ {
+			w.NewWheelTimerFunc(time.Duration(i%1000+1)*time.Hour, f, i)
+		}
+		gc(b)
+		runtime.KeepAlive(w)
+	})
+	b.Run("slab", func(b *testing.B) {
+		s := NewSlabWheel(time.Hour)
+		defer s.Stop()
+		h := s.RegisterHandler(func(time.Time, uint64) {})
+		for i := 0; i < timers; i++ {
+			s.AddTimer(time.Duration(i%1000+1)*time.Hour, 0, h, uint64(i))
+		}
+		gc(b)
+		runtime.KeepAlive(s)
+	})
+}
@@ -1,6 +1,7 @@
 package timer
 
 import (
+	"context"
 	"runtime"
 	"time"
 )
@@ -55,19 +56,19 @@ func (ws *wheel_shard) NewWSTimerFunc(d time.Duration, f func(time.Time, ...inte
 }
 
 // ticker
-func (ws *wheel_shard) NewTicker(d time.Duration) *Ticker {
+func (ws *wheel_shard) NewTicker(d time.Duration, opts ...TickerOption) *Ticker {
 	pid := ws.GetPid()
-	return ws.wheels[pid].NewTicker(d)
+	return ws.wheels[pid].NewTicker(d, opts...)
 }
 
-func (ws *wheel_shard) TickFunc(d time.Duration, f func()) *Ticker {
+func (ws *wheel_shard) TickFunc(d time.Duration, f func(), opts ...TickerOption) *Ticker {
 	pid := ws.GetPid()
-	return ws.wheels[pid].TickFunc(d, f)
+	return ws.wheels[pid].TickFunc(d, f, opts...)
 }
 
-func (ws *wheel_shard) Tick(d time.Duration) <-chan time.Time {
+func (ws *wheel_shard) Tick(d time.Duration, opts ...TickerOption) <-chan time.Time {
 	pid := ws.GetPid()
-	return ws.wheels[pid].Tick(d)
+	return ws.wheels[pid].Tick(d, opts...)
 }
 
 // Timer
@@ -91,6 +92,11 @@ func (ws *wheel_shard) NewTimer(d time.Duration) *Timer {
 	return ws.wheels[pid].NewTimer(d)
 }
 
+func (ws *wheel_shard) NewPreciseTimer(d time.Duration) *Timer {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewPreciseTimer(d)
+}
+
 func (ws *wheel_shard) NewTimerFunc(d time.Duration, callback func(time.Time, ...interface{}), arg ...interface{}) *Timer {
 	pid := ws.GetPid()
 	return ws.wheels[pid].NewTimerFunc(d, callback, arg...)
@@ -109,3 +115,62 @@ func (ws *wheel_shard) NewWheelTimerFunc(d time.Duration, f func(time.Time, ...i
 	pid := ws.GetPid()
 	return ws.wheels[pid].NewWheelTimerFunc(d, f, arg...)
 }
+
+func (ws *wheel_shard) NewWheelTimerHandle(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) Handle {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewWheelTimerHandle(d, f, arg...)
+}
+
+func (ws *wheel_shard) NewPreciseTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewPreciseTimerFunc(d, f, arg...)
+}
+
+func (ws *wheel_shard) NewWheelTimerInfoFunc(d time.Duration, f func(FireInfo, ...interface{}), arg ...interface{}) *WheelTimer {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewWheelTimerInfoFunc(d, f, arg...)
+}
+
+func (ws *wheel_shard) NewWheelTimerCtxFunc(d time.Duration, f func(context.Context, time.Time, ...interface{}), arg ...interface{}) *WheelTimer {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewWheelTimerCtxFunc(d, f, arg...)
+}
+
+func (ws *wheel_shard) NewDynamicTimer(first time.Duration, f func(FireInfo) (next time.Duration, stop bool)) *WheelTimer {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewDynamicTimer(first, f)
+}
+
+func (ws *wheel_shard) TickInfoFunc(d time.Duration, f func(FireInfo), opts ...TickerOption) *Ticker {
+	pid := ws.GetPid()
+	return ws.wheels[pid].TickInfoFunc(d, f, opts...)
+}
+
+func (ws *wheel_shard) NewEventTicker(d time.Duration, opts ...TickerOption) *EventTicker {
+	pid := ws.GetPid()
+	return ws.wheels[pid].NewEventTicker(d, opts...)
+}
+
+// coarse clock: 所有 P 都读第一个 wheel, 只有一个时钟源, 不会因为不同 wheel 的 tick 相位不同而倒退。
+// clock 每个 tick 才写一次, 多个 P 只读不会争用 cacheline
+func (ws *wheel_shard) Now() time.Time {
+	return ws.wheels[0].Now()
+}
+
+func (ws *wheel_shard) Since(t time.Time) time.Duration {
+	return ws.wheels[0].Since(t)
+}
+
+func (ws *wheel_shard) Until(t time.Time) time.Duration {
+	return ws.wheels[0].Until(t)
+}
+
+// SetTick 修改所有 wheel 的 tick, 见 Wheel.SetTick
+func (ws *wheel_shard) SetTick(d time.Duration) error {
+	for i := 0; i < len(ws.wheels); i++ {
+		if err := ws.wheels[i].SetTick(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -339,6 +339,12 @@ func TestTimerPoolReusesReleasedTimers(t *testing.T) {
 	if w.PoolNewCount() == -1 {
 		t.Skip("timer pool does not expose PoolNewCount")
 	}
+	if timerDebug {
+		t.Skip("released timers are not reused in timerdebug mode")
+	}
+	if raceEnabled {
+		t.Skip("sync.Pool drops items randomly under the race detector")
+	}
 
 	timer := w.NewTimer(time.Second)
 	if got := w.PoolNewCount(); got != 1 {
@@ -426,3 +432,124 @@ func BenchmarkWheelShardTimerParallel(b *testing.B) {
 		b.Fatalf("w.Timers() = %d, expected 0", w.Timers())
 	}
 }
+
+// newIdleTestWheel 创建一个 tick 很大的时间轮, 内部 run goroutine 基本不会触发 onTick, 由测试手动推进。
+func newIdleTestWheel(t *testing.T, opts ...Option) *Wheel {
+	t.Helper()
+	return newTestWheel(t, time.Hour, opts...)
+}
+
+func levelEmpty(tv []timerList) bool {
+	for i := range tv {
+		if !tv[i].Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// advanceUntilFired 手动推进时间轮直到 timer 被取出执行, 返回执行时的 jiffies。
+// 低层时间轮为空时直接跳到下一个 cascade 边界, 避免逐 tick 推进上亿次。
+func advanceUntilFired(t *testing.T, w *Wheel, tm *WheelTimer, limit uint64) uint64 {
+	t.Helper()
+
+	for {
+		w.Lock()
+		if tm.list == nil {
+			w.Unlock()
+			return atomic.LoadUint64(&w.jiffies) - 1
+		}
+		if w.jiffies > limit {
+			w.Unlock()
+			t.Fatalf("timer not fired before jiffies %d, expected fire at %d", limit, tm.expires)
+		}
+		//第 l 层非空时, 下一次需要处理的是第 l 层的 cascade 边界; 全空时只剩 overflow, 等最高层 cascade
+		step := uint64(1)
+		level := 0
+		for level < len(w.tv) && levelEmpty(w.tv[level]) {
+			level++
+		}
+		if level == len(w.tv) {
+			level--
+		}
+		if level > 0 {
+			step = 1 << w.levelShift(level)
+		}
+		w.jiffies = (w.jiffies + step - 1) / step * step
+		w.Unlock()
+		w.onTick()
+	}
+}
+
+// TestLongTimerFiresAtEachLevelBoundary 测试各层时间轮边界和超长 timer 的触发时间。
+// 功能点：超过最高层范围(默认 1<<32 个 tick)的 timer 不能被截断提前触发，各层边界上的 timer 都应在 expires 那个 tick 执行；
+// 通过 WithGeometry 修改层级后同样成立。
+// 方法：使用手动推进的时间轮，从非对齐的 jiffies 开始添加 timer，跳过空闲 tick 推进，检查 timer 被取出时的 jiffies。
+func TestLongTimerFiresAtEachLevelBoundary(t *testing.T) {
+	geometries := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "default"},
+		{name: "4-3-3", opts: []Option{WithGeometry(4, 3, 3)}},
+		{name: "10-8-4", opts: []Option{WithGeometry(10, 8, 4)}},
+	}
+
+	for _, g := range geometries {
+		t.Run(g.name, func(t *testing.T) {
+			w := newIdleTestWheel(t, g.opts...)
+			ticks := map[string]uint64{
+				"overflow first":  w.maxIdx,
+				"overflow second": 2*w.maxIdx + 7,
+			}
+			for l := 1; l < len(w.tv); l++ {
+				ticks[fmt.Sprintf("tv%d last", l)] = 1<<w.levelShift(l) - 1
+				ticks[fmt.Sprintf("tv%d first", l+1)] = 1 << w.levelShift(l)
+			}
+			ticks[fmt.Sprintf("tv%d last", len(w.tv))] = w.maxIdx - 1
+			if g.name == "default" {
+				ticks["sixty days at 1ms"] = durationToTicks(60*24*time.Hour, time.Millisecond)
+			}
+
+			for name, n := range ticks {
+				t.Run(name, func(t *testing.T) {
+					w := newIdleTestWheel(t, g.opts...)
+					w.jiffies = 12345
+
+					//tick 为 1 小时的时间轮无法用 time.Duration 表示 60 天个 tick, 直接按 tick 数设置 expires
+					tm := w.newTimer(0, 0, func(time.Time, ...interface{}) {})
+					tm.expires = w.jiffies + n
+					w.addTimer(tm)
+					expires := tm.expires
+					if n >= w.maxIdx && tm.list != &w.overflow {
+						t.Fatalf("timer of %d ticks not in overflow list, expected overflow", n)
+					}
+					if got := w.RealTimers(); got != 1 {
+						t.Fatalf("RealTimers() = %d, expected 1", got)
+					}
+
+					if got := advanceUntilFired(t, w, tm, expires+w.maxIdx); got != expires {
+						t.Fatalf("timer fired at jiffies %d, expected %d", got, expires)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestWithGeometryRejectsInvalidLayout 测试 WithGeometry 的参数校验。
+// 功能点：bits 必须为正、每层最多 16 bit、层数至少 2 层、总 bit 数不能超过 63，否则应 panic。
+// 方法：对每组非法参数用 defer recover 捕获 panic。
+func TestWithGeometryRejectsInvalidLayout(t *testing.T) {
+	tests := [][3]int{{0, 6, 5}, {8, 0, 5}, {8, 6, 1}, {16, 16, 5}, {40, 6, 2}, {8, 17, 3}}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("WithGeometry(%d, %d, %d) did not panic, expected panic", tt[0], tt[1], tt[2])
+				}
+			}()
+			WithGeometry(tt[0], tt[1], tt[2])
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package timer
 
 import (
+	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/jursonmo/timer/ilist"
@@ -44,9 +46,13 @@ const (
 // todo:按道理timer excute完后可以Stop() 和 Reset(); 同时timer in sync.Pool 是不能做任何操作的
 
 type WheelTimer = timer
+
+// timerList 是 timer 的侵入式链表, 遍历直接得到 *timer, 不需要类型断言
+type timerList = ilist.List[*timer]
+
 type timer struct {
-	ilist.Entry
-	list *ilist.List
+	ilist.Entry[*timer]
+	list *timerList
 	w    *Wheel
 
 	expires uint64
@@ -54,6 +60,44 @@ type timer struct {
 	state   int
 	f       func(time.Time, ...interface{})
 	arg     []interface{}
+	argBuf  [1]interface{} //sendTime/goFunc 等内置 callback 只有一个参数, arg 指向这里, 不用分配 slice
+
+	infoF func(FireInfo, ...interface{})                   //不为 nil 时代替 f 执行, 见 NewWheelTimerInfoFunc
+	ctxF  func(context.Context, time.Time, ...interface{}) //不为 nil 时代替 f 执行, 见 NewWheelTimerCtxFunc
+	dynF  func(FireInfo) (time.Duration, bool)             //不为 nil 时代替 f 执行, 返回下次的间隔, 见 NewDynamicTimer
+	async bool                                             //f/infoF 在新的 goroutine 中执行 arg[0], 见 newFuncTimer 和 watchdog.spawn
+
+	precise  bool          //精确模式, 见 NewPreciseTimerFunc
+	deadline int64         //请求的到期时间(UnixNano), 精确模式按它派发
+	interval time.Duration //请求的周期, 不取整到 tick
+	fires    uint64        //执行次数
+
+	mode   int32  //PeriodMode, 周期 timer 重新调度的方式; SetPeriodMode 和 tick goroutine 并发读写, 用 atomic
+	missed uint64 //见 Missed(), callback 可能在别的 goroutine 中读, 用 atomic 读写
+	gen    uint32 //每次 Release 加 1, 用来识别 Release 之后还在使用的旧 handle
+
+	busy    int32 //到期取出后到 callback 执行完之前为 1, 见 StopWait
+	stopReq bool  //StopWait 要求执行完后不再重新加入时间轮, 由 w.Lock 保护
+
+	cancel   context.CancelFunc //正在执行的 ctx callback 的 cancel, 由 w.Lock 保护
+	canceled bool               //ctx callback 还没开始执行就被 Stop/Reset, 由 w.Lock 保护
+
+	group *Group //数量配额所属的 group, 见 Wheel.NewGroup
+
+	qs   uint64 //WithQueuedAdd 模式下的状态和 generation, 见 qsNone
+	qseq uint32 //每次无锁 add 加 1, 用来识别队列中过期的操作
+
+	tickSeq uint32 //计算 expires/period 时 tick 的序号, 和 w.tickSeq 不同说明之后 SetTick 过, 见 addLocked
+
+	slack    time.Duration //SetSlack 设置的允许推迟的时间, slackSet 为 false 时用时间轮的 WithSlack
+	slackSet bool
+	slacked  uint64 //slack 推迟了多少个 tick, 周期 timer 按原来的 expires 计算下一次
+
+	level int32  //t 在 tv 中时所在的层, 见 unlink
+	slot  uint32 //t 在 tv 中时所在的槽
+
+	hop    Handle //MultiWheel 中 t 在 parked 列表时, 负责把 t 移到更细的时间轮的 timer, 由 w.Lock 保护
+	hopSeq uint32 //每次 park 加 1, 用来识别已经过期的 hop
 }
 
 func Timers() int {
@@ -66,18 +110,68 @@ func NewWheelTimerFunc(d time.Duration, f func(time.Time, ...interface{}), arg .
 	return defaultWheelShard.NewWheelTimerFunc(d, f, arg...)
 }
 
+// Stop 停止 timer, 见 TryStop
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
 func (t *timer) Stop() bool {
-	return t.w.delTimer(t)
+	return t.TryStop() == nil
 }
 
+// ResetTimer 修改还没有到期或者已经 Stop 的 timer 的到期时间和周期
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
 func (t *timer) ResetTimer(d time.Duration, period time.Duration) bool {
-	return t.w.resetTimer(t, d, period)
+	return t.w.resetTimer(t, t.curGen(), d, period)
 }
 
+// Release 把 timer 放回 pool
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
 func (t *timer) Release() {
-	t.w.releaseTimer(t)
+	t.release(t.curGen())
+}
+
+// release 返回 t 是否真的放回了 pool
+func (t *timer) release(gen uint32) bool {
+	//自动回收模式下一次性 timer 由 wheel 回收, 这里的 t 可能已经被别人复用了, 周期 timer 仍由调用者 Release
+	if t.w.autoRelease && t.period == 0 {
+		return false
+	}
+	return t.w.releaseTimer(t, gen)
 }
 
 func (t *timer) Info() string {
-	return fmt.Sprintf("expires:%d, period:%d, args:%v", t.expires, t.period, t.arg)
+	return timerInfo(t.expires, t.period, t.arg)
+}
+
+func timerInfo(expires, period uint64, arg []interface{}) string {
+	return fmt.Sprintf("expires:%d, period:%d, args:%v", expires, period, arg)
+}
+
+// SetPeriodMode 设置周期 timer 的调度方式, 下一次重新调度时生效。
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会修改复用这个对象的新 timer;
+// 用 Handle.SetPeriodMode 或 Ticker.SetPeriodMode。
+func (t *timer) SetPeriodMode(mode PeriodMode) {
+	t.w.setPeriodMode(t, t.curGen(), mode)
+}
+
+// SetSlack 设置 timer 允许推迟触发的时间, 覆盖时间轮的 WithSlack, d 为 0 表示不推迟。
+// 马上对时间轮中的 timer 生效, 周期 timer 之后每次加入时间轮都按 d 推迟, 见 WithSlack
+//
+// Deprecated: 使用调用时的 generation, Release 之后还保留着的旧 *WheelTimer 会操作到复用这个对象的新 timer;
+// 用 NewWheelTimerHandle 或 WheelTimer.Handle 返回的 Handle 操作, 见 Handle。
+func (t *timer) SetSlack(d time.Duration) {
+	t.w.setSlack(t, t.curGen(), d)
+}
+
+// Missed 返回周期 timer 错过的周期数, 只在 callback 中调用有意义:
+// FixedRate 表示本次执行落后调度时间多少个周期, SkipMissed 表示本次执行之前跳过了多少个周期, FixedDelay 总是 0。
+// missed 在 tick goroutine 中更新, 用 atomic 读; callback 在别的 goroutine 中执行时(TickFunc)读到的可能已经是
+// 下一次调度的值, 需要和本次执行对应的准确值时用 FireInfo.Missed
+func (t *timer) Missed() uint64 {
+	return atomic.LoadUint64(&t.missed)
 }